@@ -0,0 +1,201 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+)
+
+type (
+	BatchHandler        func(*BatchCtx) Error
+	BatchMiddlewareFunc func(BatchHandler) BatchHandler
+)
+
+// BatchCtx is a batch request. Batch middlewares get it before calls dispatch and can reject the whole batch by
+// returning error, reject single calls, remove or reorder calls. Calls can't be added, batch with call which is
+// not parsed by server gets internal error. Responses are written in order of Calls.
+type BatchCtx struct {
+	R *http.Request
+
+	// RequestID is an HTTP request id.
+	RequestID string
+	// Calls are parsed batch calls. Calls which are failed to parse, e.g. with invalid request error, have
+	// response already.
+	Calls []*Call
+
+	size int
+}
+
+// Call is a parsed call of batch. Calls are created by server only.
+type Call struct {
+	// Index is an index of call in batch request.
+	Index  int
+	ID     ID
+	Method string
+	Params []byte
+
+	// Response is a call response, it is set when call is dispatched. Calls with response set by batch
+	// middleware before dispatch are not dispatched.
+	Response []byte
+
+	id      string
+	rawID   []byte
+	service *Service
+	servers []*Server
+}
+
+// Context returns request context.
+func (ctx *BatchCtx) Context() context.Context {
+	return ctx.R.Context()
+}
+
+// Reject sets error response of call, so call is not dispatched.
+func (call *Call) Reject(err Error) {
+	call.Response = responseError(call.rawID, err)
+}
+
+// UseBatch appends a batch middleware to server. This middleware is called for each batch request, single calls
+// are not passed to batch middlewares.
+func (s *Server) UseBatch(middlewares ...BatchMiddlewareFunc) {
+	s.batchMiddlewares = append(s.batchMiddlewares, middlewares...)
+}
+
+// handleBatch process batch elements. Nil is returned if there is no call to respond.
+func (s *Server) handleBatch(r *http.Request, requestID string, batch [][]byte) []byte {
+	ctx := &BatchCtx{
+		R:         r,
+		RequestID: requestID,
+		Calls:     make([]*Call, len(batch)),
+		size:      len(batch),
+	}
+
+	for i, data := range batch {
+		ctx.Calls[i] = s.parseCall(r, requestID, data, i, len(batch))
+	}
+
+	if s.options.DuplicateID != DuplicateIDAllow {
+		if duplicates := duplicateCalls(ctx.Calls); len(duplicates) > 0 {
+			if s.options.DuplicateID == DuplicateIDRejectBatch {
+				return responseError(nullID, errDuplicateID())
+			}
+
+			for _, call := range duplicates {
+				call.Reject(errDuplicateID())
+			}
+		}
+	}
+
+	if hook := s.options.Hooks.OnBatch; hook != nil {
+		hook(BatchEvent{R: r, RequestID: requestID, Calls: ctx.Calls})
+	}
+
+	h := s.dispatch
+	for i := len(s.batchMiddlewares) - 1; i >= 0; i-- {
+		h = s.batchMiddlewares[i](h)
+	}
+
+	if err := h(ctx); err != nil {
+		return responseError(nullID, err)
+	}
+
+	var buffer bytes.Buffer
+
+	for _, call := range ctx.Calls {
+		if call == nil || call.Response == nil {
+			continue
+		}
+
+		if buffer.Len() == 0 {
+			buffer.WriteString("[")
+		} else {
+			buffer.WriteString(",")
+		}
+		buffer.Write(call.Response)
+	}
+
+	if buffer.Len() == 0 {
+		return nil
+	}
+
+	buffer.WriteString("]")
+
+	return buffer.Bytes()
+}
+
+// dispatch calls batch services concurrently. Panic of a call is raised again after all calls are finished.
+func (s *Server) dispatch(ctx *BatchCtx) Error {
+	// call without response and service is not parsed by server, e.g. it is added by batch middleware
+	for _, call := range ctx.Calls {
+		if call == nil || call.Response == nil && call.service == nil {
+			return ErrInternalJSON()
+		}
+	}
+
+	var (
+		wg         sync.WaitGroup
+		panicOnce  sync.Once
+		panicValue interface{}
+	)
+
+	for _, call := range ctx.Calls {
+		if call.Response != nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(call *Call) {
+			defer wg.Done()
+
+			// net/http recovers panics of handler goroutine only, so panic of call is raised there
+			defer func() {
+				if rvr := recover(); rvr != nil {
+					panicOnce.Do(func() {
+						panicValue = rvr
+					})
+				}
+			}()
+
+			call.Response = s.callService(ctx.R, ctx.RequestID, call, ctx.size)
+		}(call)
+	}
+
+	wg.Wait()
+
+	if panicValue != nil {
+		panic(panicValue)
+	}
+
+	return nil
+}
+
+// duplicateCalls returns calls, which id is used by other calls. Calls without id and calls which are failed
+// to parse are skipped.
+func duplicateCalls(calls []*Call) []*Call {
+	var duplicates []*Call
+
+	seen := make(map[string]*Call, len(calls))
+
+	for _, call := range calls {
+		if call.Response != nil || call.ID.Kind() == IDNone {
+			continue
+		}
+
+		key := call.ID.key()
+
+		first, ok := seen[key]
+		if !ok {
+			seen[key] = call
+			continue
+		}
+
+		// first call with id is added once, when the first duplicate is found
+		if first != nil {
+			duplicates = append(duplicates, first)
+			seen[key] = nil
+		}
+		duplicates = append(duplicates, call)
+	}
+
+	return duplicates
+}
@@ -0,0 +1,261 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestUseBatch(t *testing.T) {
+	var tc = []struct {
+		name       string
+		middleware BatchMiddlewareFunc
+		in, out    string
+		status     int
+	}{
+		{
+			name: "Ordered",
+			in:   `[{"jsonrpc":"2.0","method":"echo","id":1},{"jsonrpc":"2.0","method":"div","id":2},{"jsonrpc":"2.0","method":"echo","id":3}]`,
+			out:  `[{"jsonrpc":"2.0","result":"0/3","id":1},{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":2},{"jsonrpc":"2.0","result":"2/3","id":3}]`,
+		},
+		{
+			name: "RejectBatch",
+			middleware: func(next BatchHandler) BatchHandler {
+				return func(ctx *BatchCtx) Error {
+					if len(ctx.Calls) > 1 {
+						return ErrInvalidRequestJSON()
+					}
+					return next(ctx)
+				}
+			},
+			in:  `[{"jsonrpc":"2.0","method":"echo","id":1},{"jsonrpc":"2.0","method":"echo","id":2}]`,
+			out: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`,
+		},
+		{
+			name: "RejectCall",
+			middleware: func(next BatchHandler) BatchHandler {
+				return func(ctx *BatchCtx) Error {
+					for _, call := range ctx.Calls {
+						if call.ID.String() == "2" {
+							call.Reject(ErrInvalidParamsJSON())
+						}
+					}
+					return next(ctx)
+				}
+			},
+			in:  `[{"jsonrpc":"2.0","method":"echo","id":1},{"jsonrpc":"2.0","method":"echo","id":2}]`,
+			out: `[{"jsonrpc":"2.0","result":"0/2","id":1},{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":2}]`,
+		},
+		{
+			name: "Reorder",
+			middleware: func(next BatchHandler) BatchHandler {
+				return func(ctx *BatchCtx) Error {
+					ctx.Calls[0], ctx.Calls[1] = ctx.Calls[1], ctx.Calls[0]
+					return next(ctx)
+				}
+			},
+			in:  `[{"jsonrpc":"2.0","method":"echo","id":1},{"jsonrpc":"2.0","method":"echo","id":2}]`,
+			out: `[{"jsonrpc":"2.0","result":"1/2","id":2},{"jsonrpc":"2.0","result":"0/2","id":1}]`,
+		},
+		{
+			name: "Responses",
+			middleware: func(next BatchHandler) BatchHandler {
+				return func(ctx *BatchCtx) Error {
+					if err := next(ctx); err != nil {
+						return err
+					}
+					// keep only the first response
+					ctx.Calls = ctx.Calls[:1]
+					return nil
+				}
+			},
+			in:  `[{"jsonrpc":"2.0","method":"echo","id":1},{"jsonrpc":"2.0","method":"echo","id":2}]`,
+			out: `[{"jsonrpc":"2.0","result":"0/2","id":1}]`,
+		},
+		{
+			name: "AddedCall",
+			middleware: func(next BatchHandler) BatchHandler {
+				return func(ctx *BatchCtx) Error {
+					ctx.Calls = append(ctx.Calls, &Call{Method: "echo"}, nil)
+					return next(ctx)
+				}
+			},
+			in:  `[{"jsonrpc":"2.0","method":"echo","id":1}]`,
+			out: `{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":null}`,
+		},
+		{
+			name: "NoCalls",
+			middleware: func(next BatchHandler) BatchHandler {
+				return func(ctx *BatchCtx) Error {
+					ctx.Calls = nil
+					return next(ctx)
+				}
+			},
+			in:     `[{"jsonrpc":"2.0","method":"echo","id":1}]`,
+			status: http.StatusNoContent,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			rpc := NewServer(Options{})
+			rpc.Register("echo", func(ctx *RequestCtx) (Result, Error) {
+				return ctx.Result(strconv.Itoa(ctx.BatchIndex) + "/" + strconv.Itoa(ctx.BatchSize))
+			})
+			if c.middleware != nil {
+				rpc.UseBatch(c.middleware)
+			}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(c.in))
+			r.Header.Set("Content-Type", "application/json")
+
+			rpc.ServeHTTP(w, r)
+
+			status := c.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if w.Code != status {
+				t.Errorf("Unexpected status. Expected %v. Got %v", status, w.Code)
+			}
+
+			if !IsJSONEqual(c.out, w.Body.String()) {
+				t.Errorf("Unexpected result. Expected %v. Got %v", c.out, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestUseBatchSingle(t *testing.T) {
+	rpc := NewServer(Options{})
+	rpc.Register("echo", func(ctx *RequestCtx) (Result, Error) {
+		return ctx.Result("ok")
+	})
+	rpc.UseBatch(func(next BatchHandler) BatchHandler {
+		return func(ctx *BatchCtx) Error {
+			return ErrInternalJSON()
+		}
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"jsonrpc":"2.0","method":"echo","id":1}`))
+	r.Header.Set("Content-Type", "application/json")
+
+	rpc.ServeHTTP(w, r)
+
+	expected := `{"jsonrpc":"2.0","result":"ok","id":1}`
+	if !IsJSONEqual(expected, w.Body.String()) {
+		t.Errorf("Unexpected result. Expected %v. Got %v", expected, w.Body.String())
+	}
+}
+
+func TestDuplicateID(t *testing.T) {
+	duplicate := `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":{"rule":"duplicate_id","reason":"id is used by several calls in batch"}},"id":%s}`
+
+	var tc = []struct {
+		name   string
+		policy DuplicateIDPolicy
+		in     string
+		out    string
+	}{
+		{
+			name:   "Allow",
+			policy: DuplicateIDAllow,
+			in:     `[{"jsonrpc":"2.0","method":"echo","id":1},{"jsonrpc":"2.0","method":"echo","id":1}]`,
+			out:    `[{"jsonrpc":"2.0","result":"ok","id":1},{"jsonrpc":"2.0","result":"ok","id":1}]`,
+		},
+		{
+			name:   "StringAndNumber",
+			policy: DuplicateIDRejectCall,
+			in:     `[{"jsonrpc":"2.0","method":"echo","id":1},{"jsonrpc":"2.0","method":"echo","id":"1"}]`,
+			out:    `[{"jsonrpc":"2.0","result":"ok","id":1},{"jsonrpc":"2.0","result":"ok","id":"1"}]`,
+		},
+		{
+			name:   "RejectCallNumber",
+			policy: DuplicateIDRejectCall,
+			in:     `[{"jsonrpc":"2.0","method":"echo","id":1},{"jsonrpc":"2.0","method":"echo","id":2},{"jsonrpc":"2.0","method":"echo","id":1.0}]`,
+			out:    `[` + fmt.Sprintf(duplicate, "1") + `,{"jsonrpc":"2.0","result":"ok","id":2},` + fmt.Sprintf(duplicate, "1.0") + `]`,
+		},
+		{
+			name:   "RejectCallString",
+			policy: DuplicateIDRejectCall,
+			in:     `[{"jsonrpc":"2.0","method":"echo","id":"a"},{"jsonrpc":"2.0","method":"echo","id":"a"},{"jsonrpc":"2.0","method":"echo","id":"a"}]`,
+			out:    `[` + fmt.Sprintf(duplicate, `"a"`) + `,` + fmt.Sprintf(duplicate, `"a"`) + `,` + fmt.Sprintf(duplicate, `"a"`) + `]`,
+		},
+		{
+			name:   "InvalidCallSkipped",
+			policy: DuplicateIDRejectCall,
+			in:     `[{"jsonrpc":"2.0","method":"echo","id":1},{"jsonrpc":"1.0","method":"echo","id":1}]`,
+			out:    `[{"jsonrpc":"2.0","result":"ok","id":1},{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":1}]`,
+		},
+		{
+			name:   "LongNumbers",
+			policy: DuplicateIDRejectCall,
+			in:     `[{"jsonrpc":"2.0","method":"echo","id":1234567890123456789012345678901234567891},{"jsonrpc":"2.0","method":"echo","id":1234567890123456789012345678901234567892}]`,
+			out:    `[{"jsonrpc":"2.0","result":"ok","id":1234567890123456789012345678901234567891},{"jsonrpc":"2.0","result":"ok","id":1234567890123456789012345678901234567892}]`,
+		},
+		{
+			name:   "RejectBatch",
+			policy: DuplicateIDRejectBatch,
+			in:     `[{"jsonrpc":"2.0","method":"echo","id":10},{"jsonrpc":"2.0","method":"echo","id":1e1}]`,
+			out:    fmt.Sprintf(duplicate, "null"),
+		},
+		{
+			name:   "RejectBatchUnique",
+			policy: DuplicateIDRejectBatch,
+			in:     `[{"jsonrpc":"2.0","method":"echo","id":null},{"jsonrpc":"2.0","method":"echo","id":0}]`,
+			out:    `[{"jsonrpc":"2.0","result":"ok","id":null},{"jsonrpc":"2.0","result":"ok","id":0}]`,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			rpc := NewServer(Options{DuplicateID: c.policy})
+			rpc.Register("echo", func(ctx *RequestCtx) (Result, Error) {
+				return ctx.Result("ok")
+			})
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(c.in))
+			r.Header.Set("Content-Type", "application/json")
+
+			rpc.ServeHTTP(w, r)
+
+			if !IsJSONEqual(c.out, w.Body.String()) {
+				t.Errorf("Unexpected result. Expected %v. Got %v", c.out, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestDuplicateIDHugeExponent(t *testing.T) {
+	rpc := NewServer(Options{DuplicateID: DuplicateIDRejectCall})
+	rpc.Register("echo", func(ctx *RequestCtx) (Result, Error) {
+		return ctx.Result("ok")
+	})
+
+	in := `[{"jsonrpc":"2.0","method":"echo","id":1e10000000},{"jsonrpc":"2.0","method":"echo","id":10e9999999},{"jsonrpc":"2.0","method":"echo","id":2}]`
+	// ids are out of float64 range, so response is compared as is
+	duplicate := `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":{"reason":"id is used by several calls in batch","rule":"duplicate_id"}},"id":%s}`
+	out := `[` + fmt.Sprintf(duplicate, "1e10000000") + `,` + fmt.Sprintf(duplicate, "10e9999999") + `,{"jsonrpc":"2.0","result":"ok","id":2}]`
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(in))
+	r.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	rpc.ServeHTTP(w, r)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Unexpected duration. Expected less than %v. Got %v", time.Second, elapsed)
+	}
+
+	if out != w.Body.String() {
+		t.Errorf("Unexpected result. Expected %v. Got %v", out, w.Body.String())
+	}
+}
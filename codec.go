@@ -0,0 +1,162 @@
+package jsonrpc
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Codec translates request and response bodies between wire encoding and JSON,
+// so that non-JSON encodings can be served by the same handler pipeline.
+type Codec interface {
+	// Decode converts request body into JSON.
+	Decode(data []byte) ([]byte, error)
+	// Encode converts JSON response into wire encoding.
+	Encode(json []byte) ([]byte, error)
+}
+
+// jsonCodec is a codec for JSON media types, it passes data as is.
+type jsonCodec struct{}
+
+func (jsonCodec) Decode(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+func (jsonCodec) Encode(json []byte) ([]byte, error) {
+	return json, nil
+}
+
+// codecEntry is a codec registered for media type.
+type codecEntry struct {
+	mediaType    string
+	responseType string
+	codec        Codec
+}
+
+// RegisterCodec registers codec for provided media type. Requests with this Content-Type are decoded
+// with codec, and responses are encoded with codec when client accepts this media type.
+func (s *Server) RegisterCodec(mediaType string, c Codec) {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType == "" {
+		panic("can not register codec with empty media type")
+	}
+
+	s.registerCodec(&codecEntry{
+		mediaType:    mediaType,
+		responseType: mediaType,
+		codec:        c,
+	})
+}
+
+func (s *Server) registerCodec(entry *codecEntry) {
+	for i, c := range s.codecs {
+		if c.mediaType == entry.mediaType {
+			s.codecs[i] = entry
+			return
+		}
+	}
+
+	s.codecs = append(s.codecs, entry)
+}
+
+// requestCodec find codec for request Content-Type header.
+func (s *Server) requestCodec(contentType string) *codecEntry {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+
+	for _, c := range s.codecs {
+		if c.mediaType == mediaType {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// responseCodec negotiate response codec with Accept header. Request codec is preferred when it is
+// acceptable for client. Returns nil if none of registered codecs is acceptable.
+func (s *Server) responseCodec(accept string, reqCodec *codecEntry) *codecEntry {
+	if strings.TrimSpace(accept) == "" {
+		return reqCodec
+	}
+
+	ranges := parseAccept(accept)
+
+	best := reqCodec
+	bestQ := acceptQuality(ranges, reqCodec.mediaType)
+
+	for _, c := range s.codecs {
+		if q := acceptQuality(ranges, c.mediaType); q > bestQ {
+			best = c
+			bestQ = q
+		}
+	}
+
+	if bestQ <= 0 {
+		return nil
+	}
+
+	return best
+}
+
+// acceptRange is a single media range from Accept header.
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept parse Accept header into media ranges, most specific ranges go first.
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return specificity(ranges[i].mediaType) > specificity(ranges[j].mediaType)
+	})
+
+	return ranges
+}
+
+// specificity returns 2 for type/subtype, 1 for type/* and 0 for */*.
+func specificity(mediaType string) int {
+	if mediaType == "*/*" {
+		return 0
+	}
+	if strings.HasSuffix(mediaType, "/*") {
+		return 1
+	}
+
+	return 2
+}
+
+// acceptQuality returns quality of media type according to the most specific matched range.
+func acceptQuality(ranges []acceptRange, mediaType string) float64 {
+	for _, r := range ranges {
+		switch {
+		case r.mediaType == mediaType,
+			r.mediaType == "*/*",
+			strings.HasSuffix(r.mediaType, "/*") && strings.HasPrefix(mediaType, r.mediaType[:len(r.mediaType)-1]):
+			return r.q
+		}
+	}
+
+	return 0
+}
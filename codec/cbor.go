@@ -0,0 +1,360 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// CBOR is a CBOR codec, see https://www.rfc-editor.org/rfc/rfc8949.
+// Byte strings are transcoded to base64 strings, tags are dropped except bignums, undefined becomes null.
+type CBOR struct{}
+
+// Decode transcode CBOR data into JSON.
+func (CBOR) Decode(data []byte) ([]byte, error) {
+	r := &reader{data: data}
+	var buf bytes.Buffer
+
+	if err := cborToJSON(r, &buf, 0); err != nil {
+		return nil, err
+	}
+
+	if r.pos != len(r.data) {
+		return nil, ErrTrailingData
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Encode transcode JSON into CBOR data.
+func (CBOR) Encode(json []byte) ([]byte, error) {
+	v, err := decodeJSON(json)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err = writeCBOR(&buf, v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+const (
+	cborUint byte = iota
+	cborNegInt
+	cborBytes
+	cborText
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+const (
+	cborIndefinite = 31
+	cborBreak      = 0xff
+)
+
+const (
+	cborTagPositiveBignum = 2
+	cborTagNegativeBignum = 3
+)
+
+// cborHead read initial byte and argument of data item.
+func cborHead(r *reader) (major byte, info byte, arg uint64, err error) {
+	c, err := r.byte()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	major, info = c>>5, c&0x1f
+
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info <= 27:
+		arg, err = r.uint(1 << (info - 24))
+	case info == cborIndefinite:
+		if major == cborUint || major == cborNegInt || major == cborTag {
+			err = ErrUnsupportedType
+		}
+	default:
+		err = ErrUnsupportedType
+	}
+
+	return major, info, arg, err
+}
+
+func cborToJSON(r *reader, buf *bytes.Buffer, depth int) error {
+	if depth > maxDepth {
+		return ErrMaxDepth
+	}
+
+	major, info, arg, err := cborHead(r)
+	if err != nil {
+		return err
+	}
+
+	switch major {
+	case cborUint:
+		buf.WriteString(strconv.FormatUint(arg, 10))
+	case cborNegInt:
+		if arg == math.MaxUint64 {
+			buf.WriteString("-18446744073709551616")
+		} else {
+			buf.WriteByte('-')
+			buf.WriteString(strconv.FormatUint(arg+1, 10))
+		}
+	case cborBytes:
+		b, err := cborString(r, major, info, arg)
+		if err != nil {
+			return err
+		}
+		writeBinary(buf, b)
+	case cborText:
+		s, err := cborString(r, major, info, arg)
+		if err != nil {
+			return err
+		}
+		writeString(buf, s)
+	case cborArray:
+		buf.WriteByte('[')
+		for i := uint64(0); info == cborIndefinite || i < arg; i++ {
+			if info == cborIndefinite && cborNextIsBreak(r) {
+				break
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err = cborToJSON(r, buf, depth+1); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case cborMap:
+		buf.WriteByte('{')
+		for i := uint64(0); info == cborIndefinite || i < arg; i++ {
+			if info == cborIndefinite && cborNextIsBreak(r) {
+				break
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err = cborKeyToJSON(r, buf); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err = cborToJSON(r, buf, depth+1); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case cborTag:
+		if arg == cborTagPositiveBignum || arg == cborTagNegativeBignum {
+			return cborBignumToJSON(r, buf, arg == cborTagNegativeBignum)
+		}
+		return cborToJSON(r, buf, depth+1)
+	case cborSimple:
+		return cborSimpleToJSON(r, buf, info, arg)
+	}
+
+	return nil
+}
+
+// cborNextIsBreak check if next byte is a break of indefinite length item and skip it.
+func cborNextIsBreak(r *reader) bool {
+	if r.pos < len(r.data) && r.data[r.pos] == cborBreak {
+		r.pos++
+		return true
+	}
+
+	return false
+}
+
+// cborString read byte or text string, indefinite length strings are concatenated from chunks.
+func cborString(r *reader, major byte, info byte, arg uint64) ([]byte, error) {
+	if info != cborIndefinite {
+		return r.bytes(arg)
+	}
+
+	var s []byte
+	for !cborNextIsBreak(r) {
+		chunkMajor, chunkInfo, chunkArg, err := cborHead(r)
+		if err != nil {
+			return nil, err
+		}
+		if chunkMajor != major || chunkInfo == cborIndefinite {
+			return nil, ErrUnsupportedType
+		}
+
+		chunk, err := r.bytes(chunkArg)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, chunk...)
+	}
+
+	return s, nil
+}
+
+// cborKeyToJSON write map key, integer keys are converted to strings.
+func cborKeyToJSON(r *reader, buf *bytes.Buffer) error {
+	if r.pos >= len(r.data) {
+		return ErrUnexpectedEnd
+	}
+
+	switch r.data[r.pos] >> 5 {
+	case cborText:
+		return cborToJSON(r, buf, 0)
+	case cborUint, cborNegInt:
+		buf.WriteByte('"')
+		if err := cborToJSON(r, buf, 0); err != nil {
+			return err
+		}
+		buf.WriteByte('"')
+		return nil
+	}
+
+	return ErrUnsupportedKey
+}
+
+func cborBignumToJSON(r *reader, buf *bytes.Buffer, negative bool) error {
+	major, info, arg, err := cborHead(r)
+	if err != nil {
+		return err
+	}
+	if major != cborBytes {
+		return ErrUnsupportedType
+	}
+
+	b, err := cborString(r, major, info, arg)
+	if err != nil {
+		return err
+	}
+
+	n := new(big.Int).SetBytes(b)
+	if negative {
+		n.Neg(n.Add(n, big.NewInt(1)))
+	}
+
+	buf.WriteString(n.String())
+	return nil
+}
+
+func cborSimpleToJSON(r *reader, buf *bytes.Buffer, info byte, arg uint64) error {
+	switch info {
+	case 20:
+		buf.WriteString("false")
+	case 21:
+		buf.WriteString("true")
+	case 22, 23: // null, undefined
+		buf.WriteString("null")
+	case 25:
+		return writeFloat(buf, float64(halfToFloat32(uint16(arg))), 32)
+	case 26:
+		return writeFloat(buf, float64(math.Float32frombits(uint32(arg))), 32)
+	case 27:
+		return writeFloat(buf, math.Float64frombits(arg), 64)
+	default:
+		return ErrUnsupportedType
+	}
+
+	return nil
+}
+
+// halfToFloat32 convert IEEE 754 half precision float to float32.
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	frac := uint32(h & 0x3ff)
+
+	switch exp {
+	case 0:
+		// zero or subnormal
+		f := float32(frac) / (1 << 24)
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	case 0x1f:
+		// Inf or NaN
+		return math.Float32frombits(sign | 0x7f800000 | frac<<13)
+	}
+
+	return math.Float32frombits(sign | (exp+127-15)<<23 | frac<<13)
+}
+
+func writeCBOR(buf *bytes.Buffer, v value) error {
+	switch v.kind {
+	case kindNull:
+		buf.WriteByte(cborSimple<<5 | 22)
+	case kindBool:
+		if v.bool {
+			buf.WriteByte(cborSimple<<5 | 21)
+		} else {
+			buf.WriteByte(cborSimple<<5 | 20)
+		}
+	case kindNumber:
+		n, err := parseNumber(v.str)
+		if err != nil {
+			return err
+		}
+		switch {
+		case n.isInt && n.int >= 0:
+			writeCBORHead(buf, cborUint, uint64(n.int))
+		case n.isInt:
+			writeCBORHead(buf, cborNegInt, uint64(-1-n.int))
+		case n.isUint:
+			writeCBORHead(buf, cborUint, n.uint)
+		default:
+			buf.WriteByte(cborSimple<<5 | 27)
+			_ = binary.Write(buf, binary.BigEndian, math.Float64bits(n.float))
+		}
+	case kindString:
+		writeCBORHead(buf, cborText, uint64(len(v.str)))
+		buf.WriteString(v.str)
+	case kindArray:
+		writeCBORHead(buf, cborArray, uint64(len(v.values)))
+		for _, elem := range v.values {
+			if err := writeCBOR(buf, elem); err != nil {
+				return err
+			}
+		}
+	case kindObject:
+		writeCBORHead(buf, cborMap, uint64(len(v.values)))
+		for i, elem := range v.values {
+			writeCBORHead(buf, cborText, uint64(len(v.keys[i])))
+			buf.WriteString(v.keys[i])
+			if err := writeCBOR(buf, elem); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeCBORHead write initial byte and argument in the shortest form.
+func writeCBORHead(buf *bytes.Buffer, major byte, arg uint64) {
+	major <<= 5
+
+	switch {
+	case arg < 24:
+		buf.WriteByte(major | byte(arg))
+	case arg <= math.MaxUint8:
+		buf.Write([]byte{major | 24, byte(arg)})
+	case arg <= math.MaxUint16:
+		buf.WriteByte(major | 25)
+		_ = binary.Write(buf, binary.BigEndian, uint16(arg))
+	case arg <= math.MaxUint32:
+		buf.WriteByte(major | 26)
+		_ = binary.Write(buf, binary.BigEndian, uint32(arg))
+	default:
+		buf.WriteByte(major | 27)
+		_ = binary.Write(buf, binary.BigEndian, arg)
+	}
+}
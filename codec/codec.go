@@ -0,0 +1,251 @@
+// Package codec provides binary wire encodings for jsonrpc server. Codecs translate request envelopes into JSON,
+// so requests are parsed by jparser and dispatched to the same handlers, and encode JSON responses back.
+package codec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+const (
+	// MsgPackType MessagePack media type.
+	MsgPackType = "application/msgpack"
+	// MsgPackLegacyType MessagePack media type used by older clients.
+	MsgPackLegacyType = "application/x-msgpack"
+	// CBORType CBOR media type.
+	CBORType = "application/cbor"
+)
+
+// maxDepth is a maximum nesting level of arrays and maps.
+const maxDepth = 512
+
+var (
+	ErrUnexpectedEnd    = errors.New("codec: unexpected end of data")
+	ErrTrailingData     = errors.New("codec: trailing data after value")
+	ErrMaxDepth         = errors.New("codec: max nesting depth exceeded")
+	ErrUnsupportedType  = errors.New("codec: unsupported value type")
+	ErrUnsupportedKey   = errors.New("codec: map key must be string or integer")
+	ErrUnsupportedFloat = errors.New("codec: NaN and Inf are not supported")
+)
+
+const hexDigits = "0123456789abcdef"
+
+// writeString write s as JSON string, invalid UTF-8 is replaced with U+FFFD.
+func writeString(buf *bytes.Buffer, s []byte) {
+	buf.WriteByte('"')
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"', c == '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(c)
+			case c == '\n':
+				buf.WriteString(`\n`)
+			case c == '\r':
+				buf.WriteString(`\r`)
+			case c == '\t':
+				buf.WriteString(`\t`)
+			case c < 0x20:
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[c>>4])
+				buf.WriteByte(hexDigits[c&0xF])
+			default:
+				buf.WriteByte(c)
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRune(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf.WriteString(`\ufffd`)
+		} else {
+			buf.Write(s[i : i+size])
+		}
+		i += size
+	}
+
+	buf.WriteByte('"')
+}
+
+// writeBinary write binary data as base64 JSON string, the same way as encoding/json does for []byte.
+func writeBinary(buf *bytes.Buffer, b []byte) {
+	buf.WriteByte('"')
+	buf.WriteString(base64.StdEncoding.EncodeToString(b))
+	buf.WriteByte('"')
+}
+
+// writeFloat write float as JSON number.
+func writeFloat(buf *bytes.Buffer, f float64, bitSize int) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return ErrUnsupportedFloat
+	}
+
+	var scratch [32]byte
+	buf.Write(strconv.AppendFloat(scratch[:0], f, 'g', -1, bitSize))
+	return nil
+}
+
+// valueKind is a kind of decoded JSON value.
+type valueKind byte
+
+const (
+	kindNull valueKind = iota
+	kindBool
+	kindNumber
+	kindString
+	kindArray
+	kindObject
+)
+
+// value is a decoded JSON value, object keys keep their order.
+type value struct {
+	kind   valueKind
+	bool   bool
+	str    string
+	keys   []string
+	values []value
+}
+
+// decodeJSON decode JSON into value tree.
+func decodeJSON(data []byte) (value, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	v, err := readValue(dec, 0)
+	if err != nil {
+		return value{}, err
+	}
+
+	if _, err = dec.Token(); err != io.EOF {
+		return value{}, ErrTrailingData
+	}
+
+	return v, nil
+}
+
+func readValue(dec *json.Decoder, depth int) (value, error) {
+	if depth > maxDepth {
+		return value{}, ErrMaxDepth
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return value{}, err
+	}
+
+	switch t := tok.(type) {
+	case nil:
+		return value{kind: kindNull}, nil
+	case bool:
+		return value{kind: kindBool, bool: t}, nil
+	case json.Number:
+		return value{kind: kindNumber, str: string(t)}, nil
+	case string:
+		return value{kind: kindString, str: t}, nil
+	case json.Delim:
+		v := value{kind: kindArray}
+		if t == '{' {
+			v.kind = kindObject
+		}
+
+		for dec.More() {
+			if v.kind == kindObject {
+				key, err := dec.Token()
+				if err != nil {
+					return value{}, err
+				}
+				v.keys = append(v.keys, key.(string))
+			}
+
+			elem, err := readValue(dec, depth+1)
+			if err != nil {
+				return value{}, err
+			}
+			v.values = append(v.values, elem)
+		}
+
+		// closing delimiter
+		if _, err = dec.Token(); err != nil {
+			return value{}, err
+		}
+
+		return v, nil
+	}
+
+	return value{}, ErrUnsupportedType
+}
+
+// number is a JSON number converted to the most compact representation.
+type number struct {
+	isInt  bool
+	isUint bool
+	int    int64
+	uint   uint64
+	float  float64
+}
+
+func parseNumber(s string) (number, error) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return number{isInt: true, int: i}, nil
+	}
+
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return number{isUint: true, uint: u}, nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return number{}, err
+	}
+
+	return number{float: f}, nil
+}
+
+// reader is a cursor over binary encoded data.
+type reader struct {
+	data []byte
+	pos  int
+}
+
+func (r *reader) byte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, ErrUnexpectedEnd
+	}
+
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) bytes(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return nil, ErrUnexpectedEnd
+	}
+
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+func (r *reader) uint(size int) (uint64, error) {
+	b, err := r.bytes(uint64(size))
+	if err != nil {
+		return 0, err
+	}
+
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+
+	return v, nil
+}
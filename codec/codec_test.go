@@ -0,0 +1,180 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+func TestMsgPackDecode(t *testing.T) {
+	var tc = []struct {
+		name, in, out string
+	}{
+		{name: "FixMap", in: "81a16101", out: `{"a":1}`},
+		{name: "NegativeFixInt", in: "ff", out: `-1`},
+		{name: "Int16", in: "d1fc18", out: `-1000`},
+		{name: "Uint64", in: "cfffffffffffffffff", out: `18446744073709551615`},
+		{name: "Float64", in: "cb3ff8000000000000", out: `1.5`},
+		{name: "Array", in: "93c0c2c3", out: `[null,false,true]`},
+		{name: "Bin", in: "c403010203", out: `"AQID"`},
+		{name: "IntKey", in: "8101a162", out: `{"1":"b"}`},
+		{name: "Escape", in: "a3220a5c", out: `"\"\n\\"`},
+		{name: "Timestamp32", in: "d6ff00000000", out: `"1970-01-01T00:00:00Z"`},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			in, _ := hex.DecodeString(c.in)
+			out, err := MsgPack{}.Decode(in)
+			if err != nil {
+				t.Errorf("Received unexpected error:\n%+v", err)
+				t.FailNow()
+			}
+
+			if string(out) != c.out {
+				t.Errorf("Unexpected result. Expected %v. Got %v", c.out, string(out))
+			}
+		})
+	}
+}
+
+func TestCBORDecode(t *testing.T) {
+	var tc = []struct {
+		name, in, out string
+	}{
+		{name: "Map", in: "a1616101", out: `{"a":1}`},
+		{name: "NegInt", in: "3903e7", out: `-1000`},
+		{name: "MinNegInt", in: "3bffffffffffffffff", out: `-18446744073709551616`},
+		{name: "Bignum", in: "c249010000000000000000", out: `18446744073709551616`},
+		{name: "Half", in: "f93c00", out: `1`},
+		{name: "HalfSubnormal", in: "f90001", out: `5.9604645e-08`},
+		{name: "Float64", in: "fb3ff199999999999a", out: `1.1`},
+		{name: "IndefiniteArray", in: "9f018202039f0405ffff", out: `[1,[2,3],[4,5]]`},
+		{name: "IndefiniteText", in: "7f657374726561646d696e67ff", out: `"streaming"`},
+		{name: "Bytes", in: "4401020304", out: `"AQIDBA=="`},
+		{name: "Simple", in: "83f4f5f6", out: `[false,true,null]`},
+		{name: "Tag", in: "c074323031332d30332d32315432303a30343a30305a", out: `"2013-03-21T20:04:00Z"`},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			in, _ := hex.DecodeString(c.in)
+			out, err := CBOR{}.Decode(in)
+			if err != nil {
+				t.Errorf("Received unexpected error:\n%+v", err)
+				t.FailNow()
+			}
+
+			if string(out) != c.out {
+				t.Errorf("Unexpected result. Expected %v. Got %v", c.out, string(out))
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var tc = []struct {
+		name  string
+		codec jsonrpc.Codec
+		in    string
+	}{
+		{name: "MsgPackTruncated", codec: MsgPack{}, in: "92c0"},
+		{name: "MsgPackTrailing", codec: MsgPack{}, in: "c0c0"},
+		{name: "MsgPackHugeArray", codec: MsgPack{}, in: "ddffffffff"},
+		{name: "MsgPackNaN", codec: MsgPack{}, in: "cb7ff8000000000000"},
+		{name: "MsgPackArrayKey", codec: MsgPack{}, in: "8190c0"},
+		{name: "CBORTruncated", codec: CBOR{}, in: "82f6"},
+		{name: "CBORUnexpectedBreak", codec: CBOR{}, in: "ff"},
+		{name: "CBORInf", codec: CBOR{}, in: "f97c00"},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			in, _ := hex.DecodeString(c.in)
+			if _, err := c.codec.Decode(in); err == nil {
+				t.Errorf("Expected error for %v", c.in)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	in := `{"jsonrpc":"2.0","method":"sum","params":{"a":[1,-2,300,-70000,4294967296,1.5,"s",true,false,null],"b":{}},"id":"x"}`
+
+	for _, c := range []jsonrpc.Codec{MsgPack{}, CBOR{}} {
+		encoded, err := c.Encode([]byte(in))
+		if err != nil {
+			t.Errorf("Received unexpected error:\n%+v", err)
+			t.FailNow()
+		}
+
+		decoded, err := c.Decode(encoded)
+		if err != nil {
+			t.Errorf("Received unexpected error:\n%+v", err)
+			t.FailNow()
+		}
+
+		if string(decoded) != in {
+			t.Errorf("Unexpected result. Expected %v. Got %v", in, string(decoded))
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	rpc := jsonrpc.NewServer(jsonrpc.Options{})
+	rpc.RegisterCodec(MsgPackType, MsgPack{})
+	rpc.RegisterCodec(CBORType, CBOR{})
+
+	rpc.Register("sum", func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		var params []int
+		if err := ctx.GetParams(&params); err != nil {
+			return nil, jsonrpc.ErrInvalidParamsJSON()
+		}
+
+		s := 0
+		for _, item := range params {
+			s += item
+		}
+
+		return ctx.Result(s)
+	})
+
+	in := []byte(`{"jsonrpc":"2.0","method":"sum","params":[1,2,3,4],"id":1}`)
+
+	for contentType, c := range map[string]jsonrpc.Codec{MsgPackType: MsgPack{}, CBORType: CBOR{}} {
+		t.Run(contentType, func(t *testing.T) {
+			body, _ := c.Encode(in)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+			r.Header.Set("Content-Type", contentType)
+
+			rpc.ServeHTTP(w, r)
+
+			if w.Header().Get("Content-Type") != contentType {
+				t.Errorf("Unexpected content type. Expected %v. Got %v", contentType, w.Header().Get("Content-Type"))
+				t.FailNow()
+			}
+
+			resp, err := c.Decode(w.Body.Bytes())
+			if err != nil {
+				t.Errorf("Received unexpected error:\n%+v", err)
+				t.FailNow()
+			}
+
+			var expected, actual interface{}
+			_ = json.Unmarshal([]byte(`{"jsonrpc":"2.0","result":10,"id":1}`), &expected)
+			_ = json.Unmarshal(resp, &actual)
+
+			if !reflect.DeepEqual(expected, actual) {
+				t.Errorf("Unexpected result. Expected %v. Got %v", expected, string(resp))
+			}
+		})
+	}
+}
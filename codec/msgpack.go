@@ -0,0 +1,380 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strconv"
+	"time"
+)
+
+// MsgPack is a MessagePack codec, see https://github.com/msgpack/msgpack/blob/master/spec.md.
+// Binary values are transcoded to base64 strings and timestamps to RFC 3339 strings.
+type MsgPack struct{}
+
+// Decode transcode MessagePack data into JSON.
+func (MsgPack) Decode(data []byte) ([]byte, error) {
+	r := &reader{data: data}
+	var buf bytes.Buffer
+
+	if err := msgpackToJSON(r, &buf, 0); err != nil {
+		return nil, err
+	}
+
+	if r.pos != len(r.data) {
+		return nil, ErrTrailingData
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Encode transcode JSON into MessagePack data.
+func (MsgPack) Encode(json []byte) ([]byte, error) {
+	v, err := decodeJSON(json)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err = writeMsgpack(&buf, v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+const msgpackTimestampExt = -1
+
+func msgpackToJSON(r *reader, buf *bytes.Buffer, depth int) error {
+	if depth > maxDepth {
+		return ErrMaxDepth
+	}
+
+	c, err := r.byte()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case c <= 0x7f: // positive fixint
+		buf.WriteString(strconv.FormatUint(uint64(c), 10))
+		return nil
+	case c >= 0xe0: // negative fixint
+		buf.WriteString(strconv.FormatInt(int64(int8(c)), 10))
+		return nil
+	case c >= 0x80 && c <= 0x8f:
+		return msgpackMapToJSON(r, buf, uint64(c&0x0f), depth)
+	case c >= 0x90 && c <= 0x9f:
+		return msgpackArrayToJSON(r, buf, uint64(c&0x0f), depth)
+	case c >= 0xa0 && c <= 0xbf:
+		return msgpackStringToJSON(r, buf, uint64(c&0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		buf.WriteString("null")
+	case 0xc2:
+		buf.WriteString("false")
+	case 0xc3:
+		buf.WriteString("true")
+	case 0xc4, 0xc5, 0xc6: // bin 8, 16, 32
+		n, err := r.uint(1 << (c - 0xc4))
+		if err != nil {
+			return err
+		}
+		b, err := r.bytes(n)
+		if err != nil {
+			return err
+		}
+		writeBinary(buf, b)
+	case 0xc7, 0xc8, 0xc9: // ext 8, 16, 32
+		n, err := r.uint(1 << (c - 0xc7))
+		if err != nil {
+			return err
+		}
+		return msgpackExtToJSON(r, buf, n)
+	case 0xca: // float 32
+		v, err := r.uint(4)
+		if err != nil {
+			return err
+		}
+		return writeFloat(buf, float64(math.Float32frombits(uint32(v))), 32)
+	case 0xcb: // float 64
+		v, err := r.uint(8)
+		if err != nil {
+			return err
+		}
+		return writeFloat(buf, math.Float64frombits(v), 64)
+	case 0xcc, 0xcd, 0xce, 0xcf: // uint 8, 16, 32, 64
+		v, err := r.uint(1 << (c - 0xcc))
+		if err != nil {
+			return err
+		}
+		buf.WriteString(strconv.FormatUint(v, 10))
+	case 0xd0, 0xd1, 0xd2, 0xd3: // int 8, 16, 32, 64
+		size := 1 << (c - 0xd0)
+		v, err := r.uint(size)
+		if err != nil {
+			return err
+		}
+		// sign extension
+		shift := 64 - 8*size
+		buf.WriteString(strconv.FormatInt(int64(v<<shift)>>shift, 10))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8: // fixext 1, 2, 4, 8, 16
+		return msgpackExtToJSON(r, buf, 1<<(c-0xd4))
+	case 0xd9, 0xda, 0xdb: // str 8, 16, 32
+		n, err := r.uint(1 << (c - 0xd9))
+		if err != nil {
+			return err
+		}
+		return msgpackStringToJSON(r, buf, n)
+	case 0xdc, 0xdd: // array 16, 32
+		n, err := r.uint(2 << (c - 0xdc))
+		if err != nil {
+			return err
+		}
+		return msgpackArrayToJSON(r, buf, n, depth)
+	case 0xde, 0xdf: // map 16, 32
+		n, err := r.uint(2 << (c - 0xde))
+		if err != nil {
+			return err
+		}
+		return msgpackMapToJSON(r, buf, n, depth)
+	default:
+		return ErrUnsupportedType
+	}
+
+	return nil
+}
+
+func msgpackStringToJSON(r *reader, buf *bytes.Buffer, n uint64) error {
+	s, err := r.bytes(n)
+	if err != nil {
+		return err
+	}
+
+	writeString(buf, s)
+	return nil
+}
+
+func msgpackArrayToJSON(r *reader, buf *bytes.Buffer, n uint64, depth int) error {
+	// each element takes at least one byte
+	if n > uint64(len(r.data)-r.pos) {
+		return ErrUnexpectedEnd
+	}
+
+	buf.WriteByte('[')
+	for i := uint64(0); i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := msgpackToJSON(r, buf, depth+1); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+
+	return nil
+}
+
+func msgpackMapToJSON(r *reader, buf *bytes.Buffer, n uint64, depth int) error {
+	// each pair takes at least two bytes
+	if n > uint64(len(r.data)-r.pos)/2 {
+		return ErrUnexpectedEnd
+	}
+
+	buf.WriteByte('{')
+	for i := uint64(0); i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := msgpackKeyToJSON(r, buf); err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+		if err := msgpackToJSON(r, buf, depth+1); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+
+	return nil
+}
+
+// msgpackKeyToJSON write map key, integer keys are converted to strings.
+func msgpackKeyToJSON(r *reader, buf *bytes.Buffer) error {
+	c, err := r.byte()
+	if err != nil {
+		return err
+	}
+
+	var n uint64
+	switch {
+	case c >= 0xa0 && c <= 0xbf:
+		n = uint64(c & 0x1f)
+	case c >= 0xd9 && c <= 0xdb:
+		if n, err = r.uint(1 << (c - 0xd9)); err != nil {
+			return err
+		}
+	case c <= 0x7f, c >= 0xe0, c >= 0xcc && c <= 0xd3:
+		r.pos--
+		buf.WriteByte('"')
+		if err = msgpackToJSON(r, buf, 0); err != nil {
+			return err
+		}
+		buf.WriteByte('"')
+		return nil
+	default:
+		return ErrUnsupportedKey
+	}
+
+	return msgpackStringToJSON(r, buf, n)
+}
+
+// msgpackExtToJSON write extension value, only timestamp extension is supported.
+func msgpackExtToJSON(r *reader, buf *bytes.Buffer, n uint64) error {
+	typ, err := r.byte()
+	if err != nil {
+		return err
+	}
+
+	data, err := r.bytes(n)
+	if err != nil {
+		return err
+	}
+
+	if int8(typ) != msgpackTimestampExt {
+		return ErrUnsupportedType
+	}
+
+	var t time.Time
+	switch len(data) {
+	case 4:
+		t = time.Unix(int64(binary.BigEndian.Uint32(data)), 0)
+	case 8:
+		v := binary.BigEndian.Uint64(data)
+		t = time.Unix(int64(v&0x3ffffffff), int64(v>>34))
+	case 12:
+		t = time.Unix(int64(binary.BigEndian.Uint64(data[4:])), int64(binary.BigEndian.Uint32(data)))
+	default:
+		return ErrUnsupportedType
+	}
+
+	writeString(buf, []byte(t.UTC().Format(time.RFC3339Nano)))
+	return nil
+}
+
+func writeMsgpack(buf *bytes.Buffer, v value) error {
+	switch v.kind {
+	case kindNull:
+		buf.WriteByte(0xc0)
+	case kindBool:
+		if v.bool {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case kindNumber:
+		n, err := parseNumber(v.str)
+		if err != nil {
+			return err
+		}
+		switch {
+		case n.isInt && n.int >= 0:
+			writeMsgpackUint(buf, uint64(n.int))
+		case n.isInt:
+			writeMsgpackInt(buf, n.int)
+		case n.isUint:
+			writeMsgpackUint(buf, n.uint)
+		default:
+			buf.WriteByte(0xcb)
+			_ = binary.Write(buf, binary.BigEndian, math.Float64bits(n.float))
+		}
+	case kindString:
+		writeMsgpackString(buf, v.str)
+	case kindArray:
+		writeMsgpackHeader(buf, len(v.values), 0x90, 0x0f, 0xdc)
+		for _, elem := range v.values {
+			if err := writeMsgpack(buf, elem); err != nil {
+				return err
+			}
+		}
+	case kindObject:
+		writeMsgpackHeader(buf, len(v.values), 0x80, 0x0f, 0xde)
+		for i, elem := range v.values {
+			writeMsgpackString(buf, v.keys[i])
+			if err := writeMsgpack(buf, elem); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func writeMsgpackUint(buf *bytes.Buffer, u uint64) {
+	switch {
+	case u <= 0x7f:
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		buf.Write([]byte{0xcc, byte(u)})
+	case u <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		_ = binary.Write(buf, binary.BigEndian, uint16(u))
+	case u <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		_ = binary.Write(buf, binary.BigEndian, uint32(u))
+	default:
+		buf.WriteByte(0xcf)
+		_ = binary.Write(buf, binary.BigEndian, u)
+	}
+}
+
+func writeMsgpackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= -32:
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt8:
+		buf.Write([]byte{0xd0, byte(i)})
+	case i >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		_ = binary.Write(buf, binary.BigEndian, int16(i))
+	case i >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		_ = binary.Write(buf, binary.BigEndian, int32(i))
+	default:
+		buf.WriteByte(0xd3)
+		_ = binary.Write(buf, binary.BigEndian, i)
+	}
+}
+
+func writeMsgpackString(buf *bytes.Buffer, s string) {
+	n := len(s)
+	switch {
+	case n <= 31:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.Write([]byte{0xd9, byte(n)})
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdb)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+	buf.WriteString(s)
+}
+
+// writeMsgpackHeader write array or map header: fix format for up to 15 elements, otherwise 16 or 32 bit length.
+func writeMsgpackHeader(buf *bytes.Buffer, n int, fix, fixMax, typ16 byte) {
+	switch {
+	case n <= int(fixMax):
+		buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(typ16)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(typ16 + 1)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
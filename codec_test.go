@@ -0,0 +1,135 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// base64Codec is a test codec which transfers JSON as base64 text.
+type base64Codec struct{}
+
+func (base64Codec) Decode(data []byte) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(string(data))
+}
+
+func (base64Codec) Encode(json []byte) ([]byte, error) {
+	return []byte(base64.StdEncoding.EncodeToString(json)), nil
+}
+
+func TestContentNegotiation(t *testing.T) {
+	rpc := NewServer(Options{
+		ContentTypes: []string{"application/json", "application/json-rpc", "application/jsonrequest"},
+	})
+	rpc.RegisterCodec("application/x-base64", base64Codec{})
+
+	sumService := SumService{}
+	rpc.Register("sum", sumService.sum)
+
+	in := `{"jsonrpc":"2.0","method":"sum","params":[1, 2, 3, 4],"id":1}`
+	out := `{"jsonrpc":"2.0","result":10,"id":1}`
+	encoded := base64.StdEncoding.EncodeToString([]byte(out))
+
+	var tc = []struct {
+		name, contentType, accept, in string
+		status                        int
+		respType, out                 string
+	}{
+		{
+			name:        "JSON",
+			contentType: "application/json",
+			in:          in,
+			status:      http.StatusOK,
+			respType:    "application/json; charset=utf-8",
+			out:         out,
+		},
+		{
+			name:        "JSONRPC",
+			contentType: "application/json-rpc; charset=utf-8",
+			accept:      "*/*",
+			in:          in,
+			status:      http.StatusOK,
+			respType:    "application/json-rpc; charset=utf-8",
+			out:         out,
+		},
+		{
+			name:        "AcceptJSON",
+			contentType: "application/jsonrequest",
+			accept:      "application/json",
+			in:          in,
+			status:      http.StatusOK,
+			respType:    "application/json; charset=utf-8",
+			out:         out,
+		},
+		{
+			name:        "CodecRequest",
+			contentType: "application/x-base64",
+			in:          base64.StdEncoding.EncodeToString([]byte(in)),
+			status:      http.StatusOK,
+			respType:    "application/x-base64",
+			out:         encoded,
+		},
+		{
+			name:        "CodecResponse",
+			contentType: "application/json",
+			accept:      "application/json;q=0.5, application/x-base64",
+			in:          in,
+			status:      http.StatusOK,
+			respType:    "application/x-base64",
+			out:         encoded,
+		},
+		{
+			name:        "NotAcceptable",
+			contentType: "application/json",
+			accept:      "text/html",
+			in:          in,
+			status:      http.StatusNotAcceptable,
+		},
+		{
+			name:        "UnsupportedMediaType",
+			contentType: "text/plain",
+			in:          in,
+			status:      http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(c.in))
+			r.Header.Set("Content-Type", c.contentType)
+			if c.accept != "" {
+				r.Header.Set("Accept", c.accept)
+			}
+
+			rpc.ServeHTTP(w, r)
+
+			if w.Code != c.status {
+				t.Errorf("Unexpected status. Expected %v. Got %v", c.status, w.Code)
+				t.FailNow()
+			}
+
+			if c.status != http.StatusOK {
+				return
+			}
+
+			if respType := w.Header().Get("Content-Type"); respType != c.respType {
+				t.Errorf("Unexpected content type. Expected %v. Got %v", c.respType, respType)
+				t.FailNow()
+			}
+
+			if c.respType == "application/x-base64" {
+				if w.Body.String() != c.out {
+					t.Errorf("Unexpected result. Expected %v. Got %v", c.out, w.Body.String())
+				}
+				return
+			}
+
+			if !IsJSONEqual(c.out, w.Body.String()) {
+				t.Errorf("Unexpected result. Expected %v. Got %v", c.out, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	errBodyTooLarge        = errors.New("jsonrpc: request body too large")
+	errUnsupportedEncoding = errors.New("jsonrpc: unsupported content encoding")
+)
+
+// Encoding is a HTTP content coding used for request and response bodies, e.g. gzip.
+type Encoding interface {
+	// NewReader returns reader which decompress data from r.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+	// NewWriter returns writer which compress data to w.
+	NewWriter(w io.Writer) io.WriteCloser
+}
+
+type gzipEncoding struct{}
+
+func (gzipEncoding) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+func (gzipEncoding) NewWriter(w io.Writer) io.WriteCloser {
+	return gzip.NewWriter(w)
+}
+
+// deflateEncoding is a "deflate" content coding, which is zlib format according to RFC 9110.
+type deflateEncoding struct{}
+
+func (deflateEncoding) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return zlib.NewReader(r)
+}
+
+func (deflateEncoding) NewWriter(w io.Writer) io.WriteCloser {
+	return zlib.NewWriter(w)
+}
+
+// encodingEntry is an encoding registered for content coding name.
+type encodingEntry struct {
+	name     string
+	encoding Encoding
+}
+
+// RegisterEncoding registers content coding, e.g. zstd. Request bodies with this Content-Encoding are
+// decompressed, and responses are compressed when client accepts this coding. Only gzip and deflate are
+// registered by default, zstd is not in standard library and must be registered by user, e.g. with
+// github.com/klauspost/compress/zstd. Registered coding replaces coding with the same name.
+func (s *Server) RegisterEncoding(name string, e Encoding) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		panic("can not register encoding with empty name")
+	}
+
+	for i, enc := range s.encodings {
+		if enc.name == name {
+			s.encodings[i].encoding = e
+			return
+		}
+	}
+
+	s.encodings = append(s.encodings, &encodingEntry{name: name, encoding: e})
+}
+
+func (s *Server) getEncoding(name string) *encodingEntry {
+	for _, enc := range s.encodings {
+		if enc.name == name {
+			return enc
+		}
+	}
+
+	return nil
+}
+
+// readBody read request body and decode it according to Content-Encoding header. Both raw and decoded
+// body sizes are limited with MaxBodySize, so compressed body can not expand beyond the limit.
+func (s *Server) readBody(body io.Reader, contentEncoding string) ([]byte, error) {
+	data, err := readLimited(body, s.options.MaxBodySize)
+	if err != nil {
+		return nil, err
+	}
+
+	codings := strings.Split(contentEncoding, ",")
+
+	// codings are listed in the order they were applied
+	for i := len(codings) - 1; i >= 0; i-- {
+		name := strings.ToLower(strings.TrimSpace(codings[i]))
+		if name == "" || name == "identity" {
+			continue
+		}
+
+		enc := s.getEncoding(name)
+		if enc == nil {
+			return nil, errUnsupportedEncoding
+		}
+
+		r, err := enc.encoding.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		data, err = readLimited(r, s.options.MaxBodySize)
+		_ = r.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// readLimited read all data from r, but not more than limit bytes.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > limit {
+		return nil, errBodyTooLarge
+	}
+
+	return data, nil
+}
+
+// responseEncoding negotiate response content coding with Accept-Encoding header. Returns nil for identity.
+func (s *Server) responseEncoding(acceptEncoding string) *encodingEntry {
+	if s.options.CompressMinSize < 0 || acceptEncoding == "" {
+		return nil
+	}
+
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params := part, ""
+		if i := strings.IndexByte(part, ';'); i >= 0 {
+			name, params = part[:i], strings.TrimSpace(part[i+1:])
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		q := 1.0
+		if strings.HasPrefix(params, "q=") {
+			var err error
+			if q, err = strconv.ParseFloat(params[len("q="):], 64); err != nil {
+				continue
+			}
+		}
+
+		qualities[name] = q
+	}
+
+	var best *encodingEntry
+	bestQ := 0.0
+
+	for _, enc := range s.encodings {
+		q, ok := qualities[enc.name]
+		if !ok {
+			q = qualities["*"]
+		}
+
+		if q > bestQ {
+			best = enc
+			bestQ = q
+		}
+	}
+
+	return best
+}
+
+// compress response data with encoding.
+func compress(enc *encodingEntry, data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	w := enc.encoding.NewWriter(&buffer)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,195 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompression(t *testing.T) {
+	rpc := NewServer(Options{
+		MaxBodySize:     1024,
+		CompressMinSize: 100,
+	})
+	rpc.Register("echo", func(ctx *RequestCtx) (Result, Error) {
+		return Result(ctx.Params), nil
+	})
+
+	small := `{"jsonrpc":"2.0","method":"echo","params":"small","id":1}`
+	large := `{"jsonrpc":"2.0","method":"echo","params":"` + strings.Repeat("a", 200) + `","id":1}`
+
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	_, _ = gw.Write([]byte(large))
+	_ = gw.Close()
+
+	var bomb bytes.Buffer
+	zw := zlib.NewWriter(&bomb)
+	_, _ = zw.Write([]byte(`{"jsonrpc":"2.0","method":"echo","params":"` + strings.Repeat("a", 1<<20) + `","id":1}`))
+	_ = zw.Close()
+
+	var tc = []struct {
+		name, contentEncoding, acceptEncoding string
+		in                                    []byte
+		status                                int
+		respEncoding                          string
+		out                                   string
+	}{
+		{
+			name:   "Identity",
+			in:     []byte(large),
+			status: http.StatusOK,
+			out:    large,
+		},
+		{
+			name:            "GzipRequest",
+			contentEncoding: "gzip",
+			in:              gzipped.Bytes(),
+			status:          http.StatusOK,
+			out:             large,
+		},
+		{
+			name:           "GzipResponse",
+			acceptEncoding: "deflate;q=0.5, gzip",
+			in:             []byte(large),
+			status:         http.StatusOK,
+			respEncoding:   "gzip",
+			out:            large,
+		},
+		{
+			name:           "DeflateResponse",
+			acceptEncoding: "deflate",
+			in:             []byte(large),
+			status:         http.StatusOK,
+			respEncoding:   "deflate",
+			out:            large,
+		},
+		{
+			name:           "BelowThreshold",
+			acceptEncoding: "gzip",
+			in:             []byte(small),
+			status:         http.StatusOK,
+			out:            small,
+		},
+		{
+			name:            "UnsupportedEncoding",
+			contentEncoding: "br",
+			in:              []byte(large),
+			status:          http.StatusUnsupportedMediaType,
+		},
+		{
+			name:   "BodyTooLarge",
+			in:     []byte(strings.Repeat(" ", 2048)),
+			status: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:            "DecompressionBomb",
+			contentEncoding: "deflate",
+			in:              bomb.Bytes(),
+			status:          http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", bytes.NewReader(c.in))
+			r.Header.Set("Content-Type", "application/json")
+			r.Header.Set("Content-Encoding", c.contentEncoding)
+			r.Header.Set("Accept-Encoding", c.acceptEncoding)
+
+			rpc.ServeHTTP(w, r)
+
+			if w.Code != c.status {
+				t.Errorf("Unexpected status. Expected %v. Got %v", c.status, w.Code)
+				t.FailNow()
+			}
+
+			if c.status != http.StatusOK {
+				return
+			}
+
+			if encoding := w.Header().Get("Content-Encoding"); encoding != c.respEncoding {
+				t.Errorf("Unexpected content encoding. Expected %v. Got %v", c.respEncoding, encoding)
+				t.FailNow()
+			}
+
+			var body io.Reader = w.Body
+			switch c.respEncoding {
+			case "gzip":
+				body, _ = gzip.NewReader(body)
+			case "deflate":
+				body, _ = zlib.NewReader(body)
+			}
+
+			resp, err := io.ReadAll(body)
+			if err != nil {
+				t.Errorf("Received unexpected error:\n%+v", err)
+				t.FailNow()
+			}
+
+			expected := strings.Replace(c.out, `"method":"echo",`, "", 1)
+			expected = strings.Replace(expected, `"params":`, `"result":`, 1)
+			if !IsJSONEqual(expected, string(resp)) {
+				t.Errorf("Unexpected result. Expected %v. Got %v", expected, string(resp))
+			}
+		})
+	}
+}
+
+// flateEncoding is a raw deflate coding registered in test as custom coding, e.g. zstd.
+type flateEncoding struct{}
+
+func (flateEncoding) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return flate.NewReader(r), nil
+}
+
+func (flateEncoding) NewWriter(w io.Writer) io.WriteCloser {
+	fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+	return fw
+}
+
+func TestRegisterEncoding(t *testing.T) {
+	rpc := NewServer(Options{CompressMinSize: 100})
+	rpc.RegisterEncoding("X-Flate", flateEncoding{})
+	rpc.Register("echo", func(ctx *RequestCtx) (Result, Error) {
+		return Result(ctx.Params), nil
+	})
+
+	params := `"` + strings.Repeat("a", 200) + `"`
+
+	var in bytes.Buffer
+	fw, _ := flate.NewWriter(&in, flate.DefaultCompression)
+	_, _ = fw.Write([]byte(`{"jsonrpc":"2.0","method":"echo","params":` + params + `,"id":1}`))
+	_ = fw.Close()
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/", &in)
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Content-Encoding", "x-flate")
+	r.Header.Set("Accept-Encoding", "x-flate")
+
+	rpc.ServeHTTP(w, r)
+
+	if encoding := w.Header().Get("Content-Encoding"); encoding != "x-flate" {
+		t.Errorf("Unexpected content encoding. Expected %v. Got %v", "x-flate", encoding)
+		t.FailNow()
+	}
+
+	resp, err := io.ReadAll(flate.NewReader(w.Body))
+	if err != nil {
+		t.Errorf("Received unexpected error:\n%+v", err)
+		t.FailNow()
+	}
+
+	expected := `{"jsonrpc":"2.0","result":` + params + `,"id":1}`
+	if !IsJSONEqual(expected, string(resp)) {
+		t.Errorf("Unexpected result. Expected %v. Got %v", expected, string(resp))
+	}
+}
@@ -1,6 +1,7 @@
 package jsonrpc
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"sync"
@@ -9,11 +10,43 @@ import (
 type RequestCtx struct {
 	R *http.Request
 
+	// ID is an unescaped string id or number id literal, use GetID to distinguish string and number ids.
 	ID     string
+	Method string
 	Params []byte
 
+	// RequestID is an HTTP request id, propagated from request id header or generated by server.
+	RequestID string
+	// CorrelationID identifies call in logs and traces. It is a RequestID for single call and RequestID with
+	// batch index suffix, e.g. "5f2b7c1e9a3d4f60-2", for call in batch.
+	CorrelationID string
+
+	// BatchIndex is an index of call in batch.
+	BatchIndex int
+	// BatchSize is a number of calls in batch, 0 if call is not a part of batch.
+	BatchSize int
+
 	mu   sync.RWMutex
 	Keys map[string]interface{}
+
+	id      ID
+	service *Service
+}
+
+// GetID returns typed request id.
+func (ctx *RequestCtx) GetID() ID {
+	return ctx.id
+}
+
+// Service returns called service.
+func (ctx *RequestCtx) Service() *Service {
+	return ctx.service
+}
+
+// Context returns request context. It is canceled when client goes away or when server Shutdown deadline
+// is exceeded.
+func (ctx *RequestCtx) Context() context.Context {
+	return ctx.R.Context()
 }
 
 // GetParams decode params with standard encoding/json package.
@@ -3,6 +3,8 @@ package jsonrpc
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/lapitskyss/jsonrpc/jparser"
 )
 
 const (
@@ -18,10 +20,37 @@ const (
 	ErrorCodeInternal int = -32603
 	// ErrorMaxBatchRequests Max requests in batch.
 	ErrorMaxBatchRequests int = -32604
+	// ErrorCodeShuttingDown Server is shutting down and does not accept new requests.
+	ErrorCodeShuttingDown int = -32000
+	// ErrorCodeRateLimited Too many requests, client should retry later.
+	ErrorCodeRateLimited int = -32001
+	// ErrorCodeUnauthorized Request has no valid credentials.
+	ErrorCodeUnauthorized int = -32002
+	// ErrorCodeForbidden Client is not allowed to call the method.
+	ErrorCodeForbidden int = -32003
+	// ErrorCodeIdempotencyConflict Idempotency key was already used with different params.
+	ErrorCodeIdempotencyConflict int = -32004
+	// ErrorCodeCircuitOpen Method is temporarily unavailable, because its recent calls failed.
+	ErrorCodeCircuitOpen int = -32005
+	// ErrorCodeBulkheadFull Method has too many concurrent calls.
+	ErrorCodeBulkheadFull int = -32006
 )
 
 type Error []byte
 
+// Code returns code of json error, 0 if error can not be decoded.
+func (e Error) Code() int {
+	var err struct {
+		Code int `json:"code"`
+	}
+
+	if json.Unmarshal(e, &err) != nil {
+		return 0
+	}
+
+	return err.Code
+}
+
 // JRPCError is a wrapper for a JSON interface value.
 type JRPCError struct {
 	Code    int         `json:"code"`
@@ -121,3 +150,116 @@ func ErrMaxBatchRequests() *JRPCError {
 func ErrMaxBatchRequestsJSON() []byte {
 	return []byte(`{"code":-32604,"message":"Max batch length exceeded"}`)
 }
+
+// ErrShuttingDown returns server shutting down error.
+func ErrShuttingDown() *JRPCError {
+	return &JRPCError{
+		Code:    ErrorCodeShuttingDown,
+		Message: "Server shutting down",
+	}
+}
+
+// ErrShuttingDownJSON return json server shutting down error.
+func ErrShuttingDownJSON() []byte {
+	return []byte(`{"code":-32000,"message":"Server shutting down"}`)
+}
+
+// ErrRateLimited returns rate limit exceeded error.
+func ErrRateLimited() *JRPCError {
+	return &JRPCError{
+		Code:    ErrorCodeRateLimited,
+		Message: "Rate limit exceeded",
+	}
+}
+
+// ErrRateLimitedJSON return json rate limit exceeded error.
+func ErrRateLimitedJSON() []byte {
+	return []byte(`{"code":-32001,"message":"Rate limit exceeded"}`)
+}
+
+// ErrUnauthorized returns unauthorized error.
+func ErrUnauthorized() *JRPCError {
+	return &JRPCError{
+		Code:    ErrorCodeUnauthorized,
+		Message: "Unauthorized",
+	}
+}
+
+// ErrUnauthorizedJSON return json unauthorized error.
+func ErrUnauthorizedJSON() []byte {
+	return []byte(`{"code":-32002,"message":"Unauthorized"}`)
+}
+
+// ErrForbidden returns forbidden error.
+func ErrForbidden() *JRPCError {
+	return &JRPCError{
+		Code:    ErrorCodeForbidden,
+		Message: "Forbidden",
+	}
+}
+
+// ErrForbiddenJSON return json forbidden error.
+func ErrForbiddenJSON() []byte {
+	return []byte(`{"code":-32003,"message":"Forbidden"}`)
+}
+
+// ErrIdempotencyConflict returns idempotency key conflict error.
+func ErrIdempotencyConflict() *JRPCError {
+	return &JRPCError{
+		Code:    ErrorCodeIdempotencyConflict,
+		Message: "Idempotency key conflict",
+	}
+}
+
+// ErrIdempotencyConflictJSON return json idempotency key conflict error.
+func ErrIdempotencyConflictJSON() []byte {
+	return []byte(`{"code":-32004,"message":"Idempotency key conflict"}`)
+}
+
+// ErrCircuitOpen returns circuit open error.
+func ErrCircuitOpen() *JRPCError {
+	return &JRPCError{
+		Code:    ErrorCodeCircuitOpen,
+		Message: "Circuit open",
+	}
+}
+
+// ErrCircuitOpenJSON return json circuit open error.
+func ErrCircuitOpenJSON() []byte {
+	return []byte(`{"code":-32005,"message":"Circuit open"}`)
+}
+
+// ErrBulkheadFull returns too many concurrent calls error.
+func ErrBulkheadFull() *JRPCError {
+	return &JRPCError{
+		Code:    ErrorCodeBulkheadFull,
+		Message: "Too many concurrent calls",
+	}
+}
+
+// ErrBulkheadFullJSON return json too many concurrent calls error.
+func ErrBulkheadFullJSON() []byte {
+	return []byte(`{"code":-32006,"message":"Too many concurrent calls"}`)
+}
+
+// errStrict returns invalid request error with violated strict mode rule in data.
+func errStrict(e *jparser.StrictError) Error {
+	err := ErrInvalidRequest()
+	err.Data = map[string]interface{}{
+		"rule":   e.Rule,
+		"reason": e.Error(),
+	}
+
+	return err.JSON()
+}
+
+// errDuplicateID returns invalid request error for batch call with duplicate id.
+func errDuplicateID() Error {
+	err := ErrInvalidRequest()
+	err.Data = map[string]interface{}{
+		"rule":   "duplicate_id",
+		"reason": "id is used by several calls in batch",
+	}
+
+	return err.JSON()
+}
@@ -0,0 +1,197 @@
+package jsonrpc
+
+import (
+	"sort"
+	"strings"
+)
+
+// Group is a namespace of methods with common prefix, e.g. group "billing" registers method "billing.charge".
+type Group struct {
+	server      *Server
+	parent      *Group
+	prefix      string
+	middlewares []MiddlewareFunc
+}
+
+// mount is a server mounted under prefix.
+type mount struct {
+	prefix string
+	server *Server
+}
+
+// MethodInfo describes registered method.
+type MethodInfo struct {
+	// Name is a full method name, e.g. "billing.charge".
+	Name string
+	// Group is a full prefix of method group, e.g. "billing", empty for method registered without group.
+	Group string
+	// Scopes are permission scopes required to call method.
+	Scopes []string
+}
+
+// Group creates group of methods with prefix. Group methods are registered as prefix and method joined with dot.
+func (s *Server) Group(prefix string) *Group {
+	if prefix == "" {
+		panic("can not create group with empty prefix")
+	}
+
+	return &Group{
+		server: s,
+		prefix: prefix,
+	}
+}
+
+// Mount mounts server under prefix, so sub server method "charge" is called as "billing.charge" with prefix
+// "billing". Middlewares of server are called before middlewares of sub server, options and hooks of sub server
+// are not used. It panics if mounted method is already registered, methods registered later in server take
+// precedence over mounted methods with the same name.
+func (s *Server) Mount(prefix string, sub *Server) {
+	if prefix == "" {
+		panic("can not mount server with empty prefix")
+	}
+
+	// cycle would make method lookup and listing recurse forever
+	if sub.mounted(s, make(map[*Server]bool)) {
+		panic("can not mount server, mount cycle detected")
+	}
+
+	for _, method := range sub.Methods() {
+		if name := joinMethod(prefix, method.Name); s.GetService(name) != nil {
+			panic("service " + name + " is already registered")
+		}
+	}
+
+	s.mounts = append(s.mounts, mount{prefix: prefix, server: sub})
+}
+
+// mounted check if target is s or is mounted to s directly or through other mounted servers.
+func (s *Server) mounted(target *Server, visited map[*Server]bool) bool {
+	if s == target {
+		return true
+	}
+
+	if visited[s] {
+		return false
+	}
+	visited[s] = true
+
+	for _, m := range s.mounts {
+		if m.server.mounted(target, visited) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Methods returns registered methods, including methods of mounted servers, sorted by name.
+func (s *Server) Methods() []MethodInfo {
+	var methods []MethodInfo
+
+	for _, service := range s.services {
+		methods = append(methods, MethodInfo{
+			Name:   service.name,
+			Group:  service.Group(),
+			Scopes: service.scopes,
+		})
+	}
+
+	for _, m := range s.mounts {
+		for _, method := range m.server.Methods() {
+			name := joinMethod(m.prefix, method.Name)
+			if _, ok := s.services[name]; ok {
+				continue
+			}
+
+			method.Name = name
+			method.Group = joinMethod(m.prefix, method.Group)
+			methods = append(methods, method)
+		}
+	}
+
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Name < methods[j].Name
+	})
+
+	return methods
+}
+
+// lookup finds service of method in server and mounted servers. Servers from s to server with service are returned,
+// their middlewares are called for the service.
+func (s *Server) lookup(method string) (*Service, []*Server) {
+	if service, ok := s.services[method]; ok {
+		return service, []*Server{s}
+	}
+
+	for _, m := range s.mounts {
+		if !strings.HasPrefix(method, m.prefix+".") {
+			continue
+		}
+
+		service, servers := m.server.lookup(method[len(m.prefix)+1:])
+		if service != nil {
+			return service, append([]*Server{s}, servers...)
+		}
+	}
+
+	return nil, nil
+}
+
+// Register new json rpc method in group. It panics if method with the same full name is already registered.
+func (g *Group) Register(method string, h Handler) *Service {
+	if method == "" {
+		panic("can not register service with empty method")
+	}
+
+	name := joinMethod(g.fullPrefix(), method)
+	if g.server.GetService(name) != nil {
+		panic("service " + name + " is already registered")
+	}
+
+	return g.server.register(name, h, g)
+}
+
+// Group creates nested group, e.g. group "invoice" of group "billing" registers method "billing.invoice.send".
+func (g *Group) Group(prefix string) *Group {
+	if prefix == "" {
+		panic("can not create group with empty prefix")
+	}
+
+	return &Group{
+		server: g.server,
+		parent: g,
+		prefix: prefix,
+	}
+}
+
+// Use appends a middleware handler to group. This middleware call for each group and nested groups service
+// request, after server middlewares.
+func (g *Group) Use(middlewares ...MiddlewareFunc) {
+	g.middlewares = append(g.middlewares, middlewares...)
+}
+
+// Prefix returns full group prefix, e.g. "billing.invoice".
+func (g *Group) Prefix() string {
+	return g.fullPrefix()
+}
+
+func (g *Group) fullPrefix() string {
+	if g.parent == nil {
+		return g.prefix
+	}
+
+	return joinMethod(g.parent.fullPrefix(), g.prefix)
+}
+
+// joinMethod joins method name parts with dot.
+func joinMethod(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+
+	if name == "" {
+		return prefix
+	}
+
+	return prefix + "." + name
+}
@@ -0,0 +1,175 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// trace returns middleware, which appends name to "trace" result of call.
+func trace(name string) MiddlewareFunc {
+	return func(next Handler) Handler {
+		return func(ctx *RequestCtx) (Result, Error) {
+			v, _ := ctx.Get("trace")
+			s, _ := v.(string)
+			ctx.Set("trace", s+name+">")
+			return next(ctx)
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	handler := func(ctx *RequestCtx) (Result, Error) {
+		v, _ := ctx.Get("trace")
+		s, _ := v.(string)
+		return ctx.Result(s + ctx.Method)
+	}
+
+	billing := NewServer(Options{})
+	billing.Use(trace("billing"))
+	billing.Register("charge", handler)
+	billing.Group("invoice").Register("send", handler).Require("invoice:send")
+
+	rpc := NewServer(Options{})
+	rpc.Use(trace("server"))
+	rpc.Register("ping", handler)
+
+	users := rpc.Group("users")
+	users.Register("get", handler)
+	admin := users.Group("admin")
+	admin.Register("delete", handler).Use(trace("service"))
+	// group middlewares apply to services registered before Use
+	users.Use(trace("users"))
+	admin.Use(trace("admin"))
+
+	rpc.Mount("billing", billing)
+
+	var tc = []struct {
+		name, method, out string
+	}{
+		{name: "Server", method: "ping", out: `"server>ping"`},
+		{name: "Group", method: "users.get", out: `"server>users>users.get"`},
+		{name: "NestedGroup", method: "users.admin.delete", out: `"server>users>admin>service>users.admin.delete"`},
+		{name: "Mount", method: "billing.charge", out: `"server>billing>billing.charge"`},
+		{name: "MountGroup", method: "billing.invoice.send", out: `"server>billing>billing.invoice.send"`},
+		{name: "NotFound", method: "billing", out: ``},
+		{name: "NotMounted", method: "charge", out: ``},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"jsonrpc":"2.0","method":"`+c.method+`","id":1}`))
+			r.Header.Set("Content-Type", "application/json")
+
+			rpc.ServeHTTP(w, r)
+
+			out := `{"jsonrpc":"2.0","result":` + c.out + `,"id":1}`
+			if c.out == "" {
+				out = `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":1}`
+			}
+
+			if !IsJSONEqual(out, w.Body.String()) {
+				t.Errorf("Unexpected result. Expected %v. Got %v", out, w.Body.String())
+			}
+		})
+	}
+
+	expected := []MethodInfo{
+		{Name: "billing.charge", Group: "billing"},
+		{Name: "billing.invoice.send", Group: "billing.invoice", Scopes: []string{"invoice:send"}},
+		{Name: "ping", Group: ""},
+		{Name: "users.admin.delete", Group: "users.admin"},
+		{Name: "users.get", Group: "users"},
+	}
+
+	if methods := rpc.Methods(); !reflect.DeepEqual(expected, methods) {
+		t.Errorf("Unexpected result. Expected %v. Got %v", expected, methods)
+	}
+
+	if service := rpc.GetService("billing.invoice.send"); service == nil || service.Group() != "invoice" {
+		t.Errorf("Unexpected service %v", service)
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	result := func(s string) Handler {
+		return func(ctx *RequestCtx) (Result, Error) { return ctx.Result(s) }
+	}
+
+	rpc := NewServer(Options{})
+	rpc.Register("ping", result("first"))
+	rpc.Register("ping", result("second"))
+	rpc.Register("users.get", result("server"))
+
+	users := NewServer(Options{})
+	users.Register("get", result("users"))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"jsonrpc":"2.0","method":"ping","id":1}`))
+	r.Header.Set("Content-Type", "application/json")
+	rpc.ServeHTTP(w, r)
+
+	expected := `{"jsonrpc":"2.0","result":"first","id":1}`
+	if !IsJSONEqual(expected, w.Body.String()) {
+		t.Errorf("Unexpected result. Expected %v. Got %v", expected, w.Body.String())
+	}
+
+	tests := []struct {
+		name     string
+		register func()
+	}{
+		{name: "Group", register: func() { rpc.Group("users").Register("get", result("group")) }},
+		{name: "Mount", register: func() { rpc.Mount("users", users) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected panic for duplicate method")
+				}
+			}()
+
+			test.register()
+		})
+	}
+}
+
+func TestMountCycle(t *testing.T) {
+	a := NewServer(Options{})
+	b := NewServer(Options{})
+	c := NewServer(Options{})
+
+	a.Mount("b", b)
+	b.Mount("c", c)
+	// the same server may be mounted several times without cycle
+	a.Mount("c", c)
+
+	tests := []struct {
+		name          string
+		parent, child *Server
+	}{
+		{name: "Self", parent: a, child: a},
+		{name: "Direct", parent: b, child: a},
+		{name: "Transitive", parent: c, child: a},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected panic for mount cycle")
+				}
+			}()
+
+			test.parent.Mount("x", test.child)
+		})
+	}
+
+	if methods := a.Methods(); len(methods) != 0 {
+		t.Errorf("Unexpected result. Expected %v. Got %v", 0, len(methods))
+	}
+}
@@ -1,132 +1,233 @@
 package jsonrpc
 
 import (
-	"bytes"
-	"io"
 	"net/http"
-	"strings"
-	"sync"
+	"time"
 
 	"github.com/lapitskyss/jsonrpc/jparser"
 )
 
 // ServeHTTP process incoming requests.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := s.requestID(r)
+	w.Header().Set(s.options.RequestIDHeader, requestID)
+
+	if hook := s.options.Hooks.OnRequestStart; hook != nil {
+		hook(RequestStartEvent{R: r, RequestID: requestID})
+	}
+
+	if hook := s.options.Hooks.OnResponseWritten; hook != nil {
+		start := time.Now()
+		hw := &hookWriter{ResponseWriter: w}
+		w = hw
+
+		defer func(r *http.Request) {
+			hook(ResponseWrittenEvent{
+				R:         r,
+				RequestID: requestID,
+				Status:    hw.status,
+				Size:      hw.size,
+				Duration:  time.Since(start),
+			})
+		}(r)
+	}
+
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
 
-	if !strings.HasPrefix(r.Header.Get("Content-Type"), s.options.ContentType) {
+	reqCodec := s.requestCodec(r.Header.Get("Content-Type"))
+	if reqCodec == nil {
 		w.WriteHeader(http.StatusUnsupportedMediaType)
 		return
 	}
 
-	json, err := io.ReadAll(r.Body)
-	if err != nil {
-		sendInternalError(w)
+	respCodec := s.responseCodec(r.Header.Get("Accept"), reqCodec)
+	if respCodec == nil {
+		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
 
-	if len(json) == 0 {
-		sendInvalidRequest(w)
+	format := responseFormat{
+		codec:    respCodec,
+		encoding: s.responseEncoding(r.Header.Get("Accept-Encoding")),
+	}
+
+	if !s.startRequest() {
+		s.send(w, format, responseError(nullID, s.options.ShutdownError))
 		return
 	}
+	defer s.inFlight.Done()
 
-	if err = jparser.ValidateBytes(json); err != nil {
-		sendParseError(w)
+	r, cancel := s.withServerContext(r)
+	defer cancel()
+
+	body, err := s.readBody(r.Body, r.Header.Get("Content-Encoding"))
+	switch err {
+	case nil:
+	case errBodyTooLarge:
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	case errUnsupportedEncoding:
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	default:
+		s.send(w, format, responseError(nullID, ErrInternalJSON()))
 		return
 	}
 
-	if jparser.IsArray(json) {
-		batchLen := jparser.ArrayLength(json)
-		if batchLen == 0 {
-			sendParseError(w)
-			return
-		}
+	if len(body) == 0 {
+		s.send(w, format, s.bodyError(r, requestID, ErrInvalidRequestJSON()))
+		return
+	}
 
-		if batchLen > s.options.BatchMaxLen {
-			sendMaxBatchRequestsError(w)
-			return
-		}
+	json, err := reqCodec.codec.Decode(body)
+	if err != nil {
+		s.send(w, format, s.bodyError(r, requestID, ErrParseJSON()))
+		return
+	}
 
-		respChan := make(chan []byte, batchLen)
+	response := s.handle(r, requestID, json)
+	if response == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
 
-		var wg sync.WaitGroup
-		wg.Add(batchLen)
+	s.send(w, format, response)
+}
 
-		for i := 0; i < batchLen; i++ {
-			data := jparser.ArrayElement(json, i)
-			go func(data []byte) {
-				respChan <- s.handleRequest(r, data)
-				wg.Done()
-			}(data)
-		}
+// handle process decoded json body, which is a single request or a batch.
+func (s *Server) handle(r *http.Request, requestID string, json []byte) []byte {
+	// single request is validated by parser
+	if !jparser.IsArray(json) {
+		return s.handleRequest(r, requestID, json, 0, 0)
+	}
 
-		wg.Wait()
-		close(respChan)
+	batch, err := jparser.SplitBatch(json)
+	if err != nil {
+		return s.bodyError(r, requestID, ErrParseJSON())
+	}
 
-		var buffer bytes.Buffer
+	batchLen := len(batch)
+	if batchLen == 0 {
+		return s.bodyError(r, requestID, ErrParseJSON())
+	}
 
-		buffer.WriteString("[")
-		for resp := range respChan {
-			buffer.Write(resp)
-			buffer.WriteString(",")
-		}
+	if batchLen > s.options.BatchMaxLen {
+		return responseError(nullID, ErrMaxBatchRequestsJSON())
+	}
 
-		response := buffer.Bytes()
-		response[len(response)-1] = ']'
+	return s.handleBatch(r, requestID, batch)
+}
 
-		send(w, response)
-		return
+// handleRequest process incoming request single time. Batch size is 0 for request which is not a part of batch.
+func (s *Server) handleRequest(r *http.Request, requestID string, json []byte, batchIndex, batchSize int) []byte {
+	call := s.parseCall(r, requestID, json, batchIndex, batchSize)
+	if call.Response != nil {
+		return call.Response
+	}
 
+	return s.callService(r, requestID, call, batchSize)
+}
+
+// parseCall parse request to call. Call which can not be dispatched, e.g. invalid request, has error response.
+func (s *Server) parseCall(r *http.Request, requestID string, json []byte, index, batchSize int) *Call {
+	call := &Call{Index: index, rawID: nullID}
+
+	var p *jparser.JParser
+	if s.options.Strict {
+		p = jparser.ParseStrict(json)
 	} else {
-		send(w, s.handleRequest(r, json))
-		return
+		p = jparser.Parse(json)
+	}
+
+	if p.Error() == jparser.ErrParseJSON {
+		return s.parseError(r, requestID, call, batchSize, ErrParseJSON())
+	}
+
+	call.rawID = responseID(p)
+	call.ID = newID(p.ID, p.IDType)
+
+	if strictErr, ok := p.Error().(*jparser.StrictError); ok {
+		return s.parseError(r, requestID, call, batchSize, errStrict(strictErr))
 	}
-}
 
-// handleRequest process incoming request single time.
-func (s *Server) handleRequest(r *http.Request, json []byte) []byte {
-	p := jparser.Parse(json)
 	if p.Error() != nil {
-		return ErrParseJSON()
+		return s.parseError(r, requestID, call, batchSize, ErrInvalidRequestJSON())
 	}
 
+	call.id = p.GetId()
+	call.Method = p.GetMethod()
+	call.Params = p.Params
+
 	if string(p.Version) != Version {
-		return responseInvalidRequest(p.ID)
+		return s.parseError(r, requestID, call, batchSize, ErrInvalidRequestJSON())
 	}
 
-	method := p.GetMethod()
-	if method == "" {
-		return responseMethodNotFound(p.ID)
+	if s.options.RejectFractionalID && call.ID.IsFractional() {
+		return s.parseError(r, requestID, call, batchSize, ErrInvalidRequestJSON())
 	}
 
-	service := s.GetService(method)
-	if service == nil {
-		return responseMethodNotFound(p.ID)
+	if call.Method == "" {
+		return s.methodNotFound(r, requestID, call, batchSize)
 	}
 
+	call.service, call.servers = s.lookup(call.Method)
+	if call.service == nil {
+		return s.methodNotFound(r, requestID, call, batchSize)
+	}
+
+	return call
+}
+
+// callService calls service of parsed call with middlewares and returns response.
+func (s *Server) callService(r *http.Request, requestID string, call *Call, batchSize int) []byte {
+	service := call.service
+
 	f := service.handler
 
 	for i := len(service.middlewares) - 1; i >= 0; i-- {
 		f = service.middlewares[i](f)
 	}
 
-	for i := len(s.middlewares) - 1; i >= 0; i-- {
-		f = s.middlewares[i](f)
+	for g := service.group; g != nil; g = g.parent {
+		for i := len(g.middlewares) - 1; i >= 0; i-- {
+			f = g.middlewares[i](f)
+		}
+	}
+
+	// middlewares of mounted servers are called after middlewares of servers they are mounted to
+	for j := len(call.servers) - 1; j >= 0; j-- {
+		for i := len(call.servers[j].middlewares) - 1; i >= 0; i-- {
+			f = call.servers[j].middlewares[i](f)
+		}
 	}
 
 	requestCtx := &RequestCtx{
 		R:      r,
-		ID:     p.GetId(),
-		Params: p.Params,
+		ID:     call.id,
+		Method: call.Method,
+		Params: call.Params,
+
+		RequestID:     requestID,
+		CorrelationID: correlationID(requestID, call.Index, batchSize),
+
+		BatchIndex: call.Index,
+		BatchSize:  batchSize,
+
+		id:      call.ID,
+		service: service,
 	}
 
 	result, err := f(requestCtx)
 	if err != nil {
-		return responseError(p.ID, err)
+		if hook := s.options.Hooks.OnHandlerError; hook != nil {
+			hook(HandlerErrorEvent{Ctx: requestCtx, Err: err})
+		}
+
+		return responseError(call.rawID, err)
 	}
 
-	return responseResult(p.ID, result)
+	return responseResult(call.rawID, result)
 }
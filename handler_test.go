@@ -30,7 +30,7 @@ func TestServeHTTP(t *testing.T) {
 		{
 			name: "OKBatch",
 			in:   `[{"jsonrpc":"2.0","method":"sum","params":[1, 2, 3, 4],"id":1}, {"jsonrpc":"2.0","method":"sum","params":[1, 2],"id":2}]`,
-			out:  `[{"jsonrpc":"2.0","id":2,"result":3}, {"jsonrpc":"2.0","id":1,"result":10}]`,
+			out:  `[{"jsonrpc":"2.0","id":1,"result":10}, {"jsonrpc":"2.0","id":2,"result":3}]`,
 		},
 		//{
 		//	name: "Notification",
@@ -52,6 +52,31 @@ func TestServeHTTP(t *testing.T) {
 			in:   `{"jsonrpc": "2.0", "method": "foobar, "params": "bar", "baz]`,
 			out:  `{"jsonrpc": "2.0", "error": {"code": -32700, "message": "Parse error"}, "id": null}`,
 		},
+		{
+			name: "NotObject",
+			in:   `1`,
+			out:  `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null}`,
+		},
+		{
+			name: "NotObjectBatch",
+			in:   `[1, "a"]`,
+			out:  `[{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null}, {"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null}]`,
+		},
+		{
+			name: "ParseErrorBatch",
+			in:   `[{"jsonrpc": "2.0", "method": "sum", "params": [1], "id": 1}, {"jsonrpc": "2.0", "method"]`,
+			out:  `{"jsonrpc": "2.0", "error": {"code": -32700, "message": "Parse error"}, "id": null}`,
+		},
+		{
+			name: "IncorrectMethodType",
+			in:   `{"jsonrpc": "2.0", "method": 1, "params": [1], "id": 1}`,
+			out:  `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": 1}`,
+		},
+		{
+			name: "NestedParams",
+			in:   `{"jsonrpc": "2.0", "method": "sum", "params": [1, 2], "meta": {"trace": [{"id": 5}]}, "id": {"a": 1}}`,
+			out:  `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null}`,
+		},
 		{
 			name: "InvalidParams",
 			in:   `{"jsonrpc": "2.0", "method": "sum", "params": "error", "id": 1 }`,
@@ -131,7 +156,7 @@ func Test_handleRequest(t *testing.T) {
 	r, _ := http.NewRequest("POST", "/", nil)
 	j := []byte(`{"jsonrpc": "2.0", "method": "sum", "params": [1, 2, 3, 4], "id": "1" }`)
 
-	res := rpc.handleRequest(r, j)
+	res := rpc.handleRequest(r, "", j, 0, 0)
 	expected := `{"jsonrpc":"2.0","result":10,"id":"1"}`
 
 	if !IsJSONEqual(expected, string(res)) {
@@ -152,7 +177,7 @@ func Benchmark_handleRequest(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		rpc.handleRequest(r, j)
+		rpc.handleRequest(r, "", j, 0, 0)
 	}
 }
 
@@ -203,3 +228,44 @@ func (ss *SumService) sum(ctx *RequestCtx) (Result, Error) {
 
 	return ctx.Result(s)
 }
+
+func TestServeHTTPStrict(t *testing.T) {
+	rpc := NewServer(Options{Strict: true})
+
+	sumService := SumService{}
+	rpc.Register("sum", sumService.sum)
+
+	var tc = []struct {
+		name, in, out string
+	}{
+		{
+			name: "OK",
+			in:   `{"jsonrpc":"2.0","method":"sum","params":[1, 2],"id":1}`,
+			out:  `{"jsonrpc":"2.0","id":1,"result":3}`,
+		},
+		{
+			name: "UnknownMember",
+			in:   `{"jsonrpc":"2.0","method":"sum","params":[1, 2],"id":1,"extra":1}`,
+			out:  `{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"Invalid Request","data":{"rule":"unknown_member","reason":"unknown member \"extra\""}}}`,
+		},
+		{
+			name: "NoID",
+			in:   `{"jsonrpc":"2.0","method":"sum","params":1}`,
+			out:  `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request","data":{"rule":"params_type","reason":"params must be an object or an array"}}}`,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(c.in))
+			r.Header.Set("Content-Type", "application/json")
+
+			rpc.ServeHTTP(w, r)
+
+			if !IsJSONEqual(c.out, w.Body.String()) {
+				t.Errorf("Unexpected result. Expected %v. Got %v", c.out, w.Body.String())
+			}
+		})
+	}
+}
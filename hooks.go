@@ -0,0 +1,180 @@
+package jsonrpc
+
+import (
+	"net/http"
+	"time"
+)
+
+// Hooks are observers of request lifecycle events. Hooks of batch calls are called concurrently. Hooks must not
+// modify events.
+type Hooks struct {
+	// OnRequestStart is called when HTTP request is received.
+	OnRequestStart func(RequestStartEvent)
+	// OnParseError is called when request body or call is not a valid JSON or is not a valid request.
+	OnParseError func(ParseErrorEvent)
+	// OnMethodNotFound is called when call method is not registered.
+	OnMethodNotFound func(MethodNotFoundEvent)
+	// OnHandlerError is called when handler, or its middleware, returns error.
+	OnHandlerError func(HandlerErrorEvent)
+	// OnResponseWritten is called when HTTP response is written.
+	OnResponseWritten func(ResponseWrittenEvent)
+	// OnBatch is called when batch is parsed, before batch middlewares.
+	OnBatch func(BatchEvent)
+}
+
+// ChainHooks returns hooks which call hooks in order, e.g. to use metrics hooks with custom ones.
+func ChainHooks(hooks ...Hooks) Hooks {
+	var chained Hooks
+
+	for _, h := range hooks {
+		chained.OnRequestStart = chainHook(chained.OnRequestStart, h.OnRequestStart)
+		chained.OnParseError = chainHook(chained.OnParseError, h.OnParseError)
+		chained.OnMethodNotFound = chainHook(chained.OnMethodNotFound, h.OnMethodNotFound)
+		chained.OnHandlerError = chainHook(chained.OnHandlerError, h.OnHandlerError)
+		chained.OnResponseWritten = chainHook(chained.OnResponseWritten, h.OnResponseWritten)
+		chained.OnBatch = chainHook(chained.OnBatch, h.OnBatch)
+	}
+
+	return chained
+}
+
+func chainHook[E any](first, second func(E)) func(E) {
+	if first == nil {
+		return second
+	}
+
+	if second == nil {
+		return first
+	}
+
+	return func(e E) {
+		first(e)
+		second(e)
+	}
+}
+
+// RequestStartEvent is an event of received HTTP request.
+type RequestStartEvent struct {
+	R         *http.Request
+	RequestID string
+}
+
+// ParseErrorEvent is an event of invalid request body or call.
+type ParseErrorEvent struct {
+	R         *http.Request
+	RequestID string
+	// BatchIndex is an index of call in batch.
+	BatchIndex int
+	// BatchSize is a number of calls in batch, 0 for single call or invalid request body.
+	BatchSize int
+	// ID is a call id, if it is parsed.
+	ID ID
+	// Err is a response error, e.g. parse error or invalid request.
+	Err Error
+}
+
+// MethodNotFoundEvent is an event of call to not registered method.
+type MethodNotFoundEvent struct {
+	R          *http.Request
+	RequestID  string
+	BatchIndex int
+	BatchSize  int
+	ID         ID
+	Method     string
+}
+
+// HandlerErrorEvent is an event of call which is failed with error.
+type HandlerErrorEvent struct {
+	Ctx *RequestCtx
+	Err Error
+}
+
+// ResponseWrittenEvent is an event of written HTTP response.
+type ResponseWrittenEvent struct {
+	R         *http.Request
+	RequestID string
+	// Status is HTTP response status.
+	Status int
+	// Size is a number of written response body bytes.
+	Size int
+	// Duration is a time from request start to written response.
+	Duration time.Duration
+}
+
+// BatchEvent is an event of parsed batch.
+type BatchEvent struct {
+	R         *http.Request
+	RequestID string
+	// Calls are parsed batch calls, invalid calls have error response already.
+	Calls []*Call
+}
+
+// hookWriter records status and size of response for OnResponseWritten hook.
+type hookWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (w *hookWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *hookWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+
+	return n, err
+}
+
+// bodyError returns error response for invalid request body and calls OnParseError hook.
+func (s *Server) bodyError(r *http.Request, requestID string, err Error) []byte {
+	if hook := s.options.Hooks.OnParseError; hook != nil {
+		hook(ParseErrorEvent{R: r, RequestID: requestID, Err: err})
+	}
+
+	return responseError(nullID, err)
+}
+
+// parseError sets error response of invalid call and calls OnParseError hook.
+func (s *Server) parseError(r *http.Request, requestID string, call *Call, batchSize int, err Error) *Call {
+	call.Reject(err)
+
+	if hook := s.options.Hooks.OnParseError; hook != nil {
+		hook(ParseErrorEvent{
+			R:          r,
+			RequestID:  requestID,
+			BatchIndex: call.Index,
+			BatchSize:  batchSize,
+			ID:         call.ID,
+			Err:        err,
+		})
+	}
+
+	return call
+}
+
+// methodNotFound sets method not found response of call and calls OnMethodNotFound hook.
+func (s *Server) methodNotFound(r *http.Request, requestID string, call *Call, batchSize int) *Call {
+	call.Reject(ErrMethodNotFoundJSON())
+
+	if hook := s.options.Hooks.OnMethodNotFound; hook != nil {
+		hook(MethodNotFoundEvent{
+			R:          r,
+			RequestID:  requestID,
+			BatchIndex: call.Index,
+			BatchSize:  batchSize,
+			ID:         call.ID,
+			Method:     call.Method,
+		})
+	}
+
+	return call
+}
@@ -0,0 +1,166 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+
+	record := func(format string, args ...interface{}) {
+		mu.Lock()
+		events = append(events, fmt.Sprintf(format, args...))
+		mu.Unlock()
+	}
+
+	rpc := NewServer(Options{
+		RequestIDGenerator: func() string { return "rid" },
+		Hooks: Hooks{
+			OnRequestStart: func(e RequestStartEvent) {
+				record("start %s", e.RequestID)
+			},
+			OnParseError: func(e ParseErrorEvent) {
+				record("parse %d/%d %s %s", e.BatchIndex, e.BatchSize, e.ID, e.Err)
+			},
+			OnMethodNotFound: func(e MethodNotFoundEvent) {
+				record("notfound %d/%d %s %s", e.BatchIndex, e.BatchSize, e.ID, e.Method)
+			},
+			OnHandlerError: func(e HandlerErrorEvent) {
+				record("handler %s %s %s", e.Ctx.Method, e.Ctx.GetID(), e.Err)
+			},
+			OnResponseWritten: func(e ResponseWrittenEvent) {
+				record("written %s %d %v", e.RequestID, e.Status, e.Size > 0)
+			},
+			OnBatch: func(e BatchEvent) {
+				record("batch %d", len(e.Calls))
+			},
+		},
+	})
+	rpc.Register("ok", func(ctx *RequestCtx) (Result, Error) {
+		return ctx.Result("ok")
+	})
+	rpc.Register("fail", func(ctx *RequestCtx) (Result, Error) {
+		return nil, ErrInternalJSON()
+	})
+
+	var tc = []struct {
+		name   string
+		method string
+		in     string
+		events []string
+	}{
+		{
+			name: "OK",
+			in:   `{"jsonrpc":"2.0","method":"ok","id":1}`,
+			events: []string{
+				"start rid",
+				"written rid 200 true",
+			},
+		},
+		{
+			name: "HandlerError",
+			in:   `{"jsonrpc":"2.0","method":"fail","id":"a"}`,
+			events: []string{
+				"start rid",
+				`handler fail a {"code":-32603,"message":"Internal error"}`,
+				"written rid 200 true",
+			},
+		},
+		{
+			name: "ParseError",
+			in:   `{"jsonrpc":"2.0",`,
+			events: []string{
+				"start rid",
+				`parse 0/0  {"code":-32700,"message":"Parse error"}`,
+				"written rid 200 true",
+			},
+		},
+		{
+			name: "InvalidBody",
+			in:   ``,
+			events: []string{
+				"start rid",
+				`parse 0/0  {"code":-32600,"message":"Invalid Request"}`,
+				"written rid 200 true",
+			},
+		},
+		{
+			name: "Batch",
+			in:   `[{"jsonrpc":"2.0","method":"ok","id":1},{"jsonrpc":"1.0","method":"ok","id":2},{"jsonrpc":"2.0","method":"div","id":3}]`,
+			events: []string{
+				"start rid",
+				`parse 1/3 2 {"code":-32600,"message":"Invalid Request"}`,
+				"notfound 2/3 3 div",
+				"batch 3",
+				"written rid 200 true",
+			},
+		},
+		{
+			name:   "MethodNotAllowed",
+			method: "GET",
+			events: []string{
+				"start rid",
+				"written rid 405 false",
+			},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			events = nil
+
+			method := c.method
+			if method == "" {
+				method = "POST"
+			}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(method, "/", bytes.NewBufferString(c.in))
+			r.Header.Set("Content-Type", "application/json")
+
+			rpc.ServeHTTP(w, r)
+
+			// batch calls are called concurrently, so only first and last events are ordered
+			got := append([]string(nil), events...)
+			expected := append([]string(nil), c.events...)
+			if len(got) > 2 {
+				sort.Strings(got[1 : len(got)-1])
+			}
+			sort.Strings(expected[1 : len(expected)-1])
+
+			if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+				t.Errorf("Unexpected result. Expected %v. Got %v", expected, events)
+			}
+		})
+	}
+}
+
+func TestChainHooks(t *testing.T) {
+	var calls []string
+
+	hooks := ChainHooks(
+		Hooks{OnBatch: func(e BatchEvent) { calls = append(calls, "first") }},
+		Hooks{OnRequestStart: func(e RequestStartEvent) { calls = append(calls, "start") }},
+		Hooks{OnBatch: func(e BatchEvent) { calls = append(calls, "second") }},
+	)
+
+	hooks.OnBatch(BatchEvent{})
+	hooks.OnRequestStart(RequestStartEvent{})
+
+	expected := "first,second,start"
+	if got := strings.Join(calls, ","); got != expected {
+		t.Errorf("Unexpected result. Expected %v. Got %v", expected, got)
+	}
+
+	if hooks.OnParseError != nil || hooks.OnResponseWritten != nil {
+		t.Errorf("Unexpected hooks. Expected not set hooks to be nil")
+	}
+}
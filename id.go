@@ -0,0 +1,244 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/lapitskyss/jsonrpc/jparser"
+)
+
+// IDKind is a kind of request id.
+type IDKind int
+
+const (
+	// IDNone is a kind of missing id, request without id is a notification.
+	IDNone IDKind = iota
+	IDNull
+	IDString
+	IDNumber
+)
+
+func (k IDKind) String() string {
+	switch k {
+	case IDNull:
+		return "null"
+	case IDString:
+		return "string"
+	case IDNumber:
+		return "number"
+	default:
+		return "none"
+	}
+}
+
+var errIDType = errors.New("id must be a string, a number or null")
+
+// maxIDExponentLen is a maximum number of significant digits of numeric id exponent, ids with longer exponent
+// are compared as is, without normalization.
+const maxIDExponentLen = 15
+
+// ID is a request id. It keeps id type, so string id "1" and number id 1 are different ids.
+type ID struct {
+	kind  IDKind
+	value string
+}
+
+// StringID returns string id.
+func StringID(s string) ID {
+	return ID{kind: IDString, value: s}
+}
+
+// NumberID returns number id, n must be a valid JSON number.
+func NumberID(n string) ID {
+	return ID{kind: IDNumber, value: n}
+}
+
+// NullID returns null id.
+func NullID() ID {
+	return ID{kind: IDNull}
+}
+
+// newID returns id from raw JSON id value. String id is unescaped, raw string content is used if it contains
+// escape sequence which can't be decoded, e.g. lone UTF-16 surrogate.
+func newID(raw []byte, typ jparser.ValueType) ID {
+	switch typ {
+	case jparser.String:
+		s, err := jparser.ParseString(raw)
+		if err != nil {
+			s = string(raw[1 : len(raw)-1])
+		}
+		return StringID(s)
+	case jparser.Number:
+		return NumberID(string(raw))
+	case jparser.Null:
+		return NullID()
+	default:
+		return ID{}
+	}
+}
+
+// Kind returns id kind.
+func (id ID) Kind() IDKind {
+	return id.kind
+}
+
+// String returns unescaped string id or number id literal, empty string for null or missing id.
+func (id ID) String() string {
+	return id.value
+}
+
+// IsNull check if id is null.
+func (id ID) IsNull() bool {
+	return id.kind == IDNull
+}
+
+// IsFractional check if number id has fractional part or exponent, e.g. 1.5 or 1e3.
+func (id ID) IsFractional() bool {
+	if id.kind != IDNumber {
+		return false
+	}
+
+	_, err := jparser.ParseInt([]byte(id.value))
+	return err == jparser.ErrUnexpectedType
+}
+
+// Equal check if ids are equal. Ids of different kinds are not equal. Numbers are compared by exact decimal value
+// without conversion to float, so 1, 1.0, 10e-1 and 1E0 are equal ids, -0 and 0 are equal ids, and integers which
+// differ in any digit are not equal. Numbers with exponent longer than 15 digits are equal only if they are written
+// the same way.
+func (id ID) Equal(other ID) bool {
+	if id.kind != other.kind {
+		return false
+	}
+
+	if id.value == other.value {
+		return true
+	}
+
+	if id.kind != IDNumber {
+		return false
+	}
+
+	return id.key() == other.key()
+}
+
+// key returns id key, ids are equal if their keys are equal. Number ids are normalized, so equal numbers have the
+// same key.
+func (id ID) key() string {
+	if id.kind != IDNumber {
+		return id.kind.String() + ":" + id.value
+	}
+
+	if n, ok := normalizeNumber(id.value); ok {
+		return "number:" + n
+	}
+
+	// not normalized number must not match normalized one, e.g. 1e1000000000000000 and 10e999999999999999
+	return "literal:" + id.value
+}
+
+// normalizeNumber returns JSON number as significant digits and exponent, e.g. "-1e2" for -100 or -1.0e2. Number
+// is normalized as text in linear time, false is returned if it is not a valid JSON number or its exponent is too
+// long.
+func normalizeNumber(n string) (string, bool) {
+	sign := ""
+	if strings.HasPrefix(n, "-") {
+		sign, n = "-", n[1:]
+	}
+
+	intEnd := digitsEnd(n, 0)
+	if intEnd == 0 {
+		return "", false
+	}
+	digits := n[:intEnd]
+
+	frac := ""
+	i := intEnd
+	if i < len(n) && n[i] == '.' {
+		fracEnd := digitsEnd(n, i+1)
+		if fracEnd == i+1 {
+			return "", false
+		}
+		frac, i = n[i+1:fracEnd], fracEnd
+	}
+
+	var exp int64
+	if i < len(n) && (n[i] == 'e' || n[i] == 'E') {
+		i++
+		negative := false
+		if i < len(n) && (n[i] == '+' || n[i] == '-') {
+			negative = n[i] == '-'
+			i++
+		}
+
+		expEnd := digitsEnd(n, i)
+		expDigits := strings.TrimLeft(n[i:expEnd], "0")
+		if expEnd == i || len(expDigits) > maxIDExponentLen {
+			return "", false
+		}
+		i = expEnd
+
+		if expDigits != "" {
+			exp, _ = strconv.ParseInt(expDigits, 10, 64)
+		}
+		if negative {
+			exp = -exp
+		}
+	}
+
+	if i != len(n) {
+		return "", false
+	}
+
+	// 1.25e1 is 125e-1, then leading and trailing zeros are removed
+	exp -= int64(len(frac))
+	mantissa := strings.TrimLeft(digits+frac, "0")
+	if mantissa == "" {
+		// -0 and 0 are equal
+		return "0", true
+	}
+
+	trimmed := strings.TrimRight(mantissa, "0")
+	exp += int64(len(mantissa) - len(trimmed))
+
+	return sign + trimmed + "e" + strconv.FormatInt(exp, 10), true
+}
+
+// digitsEnd returns offset of the first not digit byte of s from offset i.
+func digitsEnd(s string, i int) int {
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+
+	return i
+}
+
+// MarshalJSON encodes id to JSON, missing id is encoded as null.
+func (id ID) MarshalJSON() ([]byte, error) {
+	switch id.kind {
+	case IDString:
+		return json.Marshal(id.value)
+	case IDNumber:
+		return []byte(id.value), nil
+	default:
+		return []byte("null"), nil
+	}
+}
+
+// UnmarshalJSON decodes id from JSON string, number or null.
+func (id *ID) UnmarshalJSON(data []byte) error {
+	value, typ, err := jparser.Get(data)
+	if err != nil {
+		return err
+	}
+
+	if typ != jparser.String && typ != jparser.Number && typ != jparser.Null {
+		return errIDType
+	}
+
+	*id = newID(value, typ)
+
+	return nil
+}
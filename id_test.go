@@ -0,0 +1,150 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIDEqual(t *testing.T) {
+	var tc = []struct {
+		name  string
+		a, b  ID
+		equal bool
+	}{
+		{name: "String", a: StringID("1"), b: StringID("1"), equal: true},
+		{name: "StringNumber", a: StringID("1"), b: NumberID("1"), equal: false},
+		{name: "Number", a: NumberID("1"), b: NumberID("1.0"), equal: true},
+		{name: "Exponent", a: NumberID("100"), b: NumberID("1e2"), equal: true},
+		{name: "NegativeZero", a: NumberID("0"), b: NumberID("-0"), equal: true},
+		{name: "LargeNumber", a: NumberID("9007199254740993"), b: NumberID("9007199254740992"), equal: false},
+		{name: "DifferentNumber", a: NumberID("1"), b: NumberID("2"), equal: false},
+		{name: "Fraction", a: NumberID("1"), b: NumberID("10e-1"), equal: true},
+		{name: "UpperExponent", a: NumberID("1E0"), b: NumberID("1.000"), equal: true},
+		{name: "NegativeFraction", a: NumberID("-0.5"), b: NumberID("-5e-1"), equal: true},
+		{name: "NegativeZeroFraction", a: NumberID("-0.0e5"), b: NumberID("0"), equal: true},
+		{name: "Sign", a: NumberID("-1"), b: NumberID("1"), equal: false},
+		{name: "LongNumber", a: NumberID("1234567890123456789012345678901234567891"), b: NumberID("1234567890123456789012345678901234567892"), equal: false},
+		{name: "HugeExponent", a: NumberID("1e10000000"), b: NumberID("10e9999999"), equal: true},
+		{name: "TooLongExponent", a: NumberID("1e1000000000000000"), b: NumberID("10e999999999999999"), equal: false},
+		{name: "Null", a: NullID(), b: NullID(), equal: true},
+		{name: "NullNone", a: NullID(), b: ID{}, equal: false},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			if equal := c.a.Equal(c.b); equal != c.equal {
+				t.Errorf("Unexpected result. Expected %v. Got %v", c.equal, equal)
+			}
+		})
+	}
+}
+
+func TestIDJSON(t *testing.T) {
+	var tc = []struct {
+		in, out string
+		kind    IDKind
+		value   string
+	}{
+		{in: `"a\"bé"`, out: `"a\"bé"`, kind: IDString, value: `a"bé`},
+		{in: ` 1.5 `, out: `1.5`, kind: IDNumber, value: `1.5`},
+		{in: `null`, out: `null`, kind: IDNull, value: ``},
+	}
+
+	for _, c := range tc {
+		var id ID
+		if err := json.Unmarshal([]byte(c.in), &id); err != nil {
+			t.Errorf("Received unexpected error:\n%+v", err)
+			continue
+		}
+
+		if id.Kind() != c.kind || id.String() != c.value {
+			t.Errorf("Unexpected result. Expected %v %v. Got %v %v", c.kind, c.value, id.Kind(), id.String())
+		}
+
+		out, err := json.Marshal(id)
+		if err != nil || string(out) != c.out {
+			t.Errorf("Unexpected result. Expected %v. Got %s %v", c.out, out, err)
+		}
+	}
+
+	var id ID
+	if err := json.Unmarshal([]byte(`{"id":1}`), &id); err == nil {
+		t.Errorf("Expected error for object id")
+	}
+}
+
+func TestServeHTTPID(t *testing.T) {
+	var ids []ID
+
+	rpc := NewServer(Options{RejectFractionalID: true})
+	rpc.Register("ping", func(ctx *RequestCtx) (Result, Error) {
+		ids = append(ids, ctx.GetID())
+		return ctx.Result(ctx.ID)
+	})
+
+	var tc = []struct {
+		name string
+		in   string
+		out  string
+		id   ID
+	}{
+		{
+			name: "EscapedString",
+			in:   `{"jsonrpc":"2.0","method":"ping","id":"ab\n"}`,
+			out:  `{"jsonrpc":"2.0","result":"ab\n","id":"ab\n"}`,
+			id:   StringID("ab\n"),
+		},
+		{
+			name: "Number",
+			in:   `{"jsonrpc":"2.0","method":"ping","id":1}`,
+			out:  `{"jsonrpc":"2.0","result":"1","id":1}`,
+			id:   NumberID("1"),
+		},
+		{
+			name: "Null",
+			in:   `{"jsonrpc":"2.0","method":"ping","id":null}`,
+			out:  `{"jsonrpc":"2.0","result":"null","id":null}`,
+			id:   NullID(),
+		},
+		{
+			name: "Fractional",
+			in:   `{"jsonrpc":"2.0","method":"ping","id":1.5}`,
+			out:  `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":1.5}`,
+		},
+		{
+			name: "Exponent",
+			in:   `{"jsonrpc":"2.0","method":"ping","id":1e3}`,
+			out:  `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":1e3}`,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			ids = nil
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(c.in))
+			r.Header.Set("Content-Type", "application/json")
+
+			rpc.ServeHTTP(w, r)
+
+			if !IsJSONEqual(c.out, w.Body.String()) {
+				t.Errorf("Unexpected result. Expected %v. Got %v", c.out, w.Body.String())
+			}
+
+			if c.id.Kind() == IDNone {
+				if len(ids) != 0 {
+					t.Errorf("Unexpected handler call with id %v", ids)
+				}
+				return
+			}
+
+			if len(ids) != 1 || !ids[0].Equal(c.id) {
+				t.Errorf("Unexpected id. Expected %v. Got %v", c.id, ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package jparser
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Canonicalize returns JSON value without insignificant whitespace and with object keys sorted, so values
+// which differ only in formatting and keys order have equal representation. Strings and numbers are copied
+// as is. Data must be a valid JSON.
+func Canonicalize(data []byte) ([]byte, error) {
+	var c canonicalizer
+
+	root, end, err := c.value(data, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if nextToken(data[end:]) != -1 {
+		return nil, ErrParseJSON
+	}
+
+	return c.write(make([]byte, 0, len(data)), root), nil
+}
+
+// canonicalizer parses data to a tree of values, which refer to data, and writes canonical representation
+// after the whole tree is parsed. So every value is written once, whatever its nesting depth.
+type canonicalizer struct {
+	values  []canonicalNode
+	members []member
+}
+
+// canonicalNode is a parsed value: a scalar, an object with members sorted by key or an array.
+type canonicalNode struct {
+	// kind is '{' for object, '[' for array and 0 for scalar.
+	kind byte
+	// raw is a scalar value.
+	raw []byte
+	// children are member indexes of object or value indexes of array.
+	children []int
+}
+
+// member is an object member.
+type member struct {
+	key     []byte
+	sortKey []byte
+	value   int
+}
+
+func (c *canonicalizer) add(v canonicalNode) int {
+	c.values = append(c.values, v)
+	return len(c.values) - 1
+}
+
+// value parses the first value in data from offset i and returns its index and end offset.
+func (c *canonicalizer) value(data []byte, i int) (int, int, error) {
+	nt := nextToken(data[i:])
+	if nt == -1 {
+		return 0, 0, ErrParseJSON
+	}
+	i += nt
+
+	switch data[i] {
+	case '{':
+		return c.object(data, i+1)
+	case '[':
+		return c.array(data, i+1)
+	case '"':
+		end, _ := stringEnd(data[i+1:])
+		if end == -1 {
+			return 0, 0, MalformedStringError
+		}
+		return c.add(canonicalNode{raw: data[i : i+1+end]}), i + 1 + end, nil
+	}
+
+	end := tokenEnd(data[i:])
+	if end == 0 {
+		return 0, 0, ErrParseJSON
+	}
+
+	return c.add(canonicalNode{raw: data[i : i+end]}), i + end, nil
+}
+
+func (c *canonicalizer) object(data []byte, i int) (int, int, error) {
+	var children []int
+
+	for {
+		nt := nextToken(data[i:])
+		if nt == -1 {
+			return 0, 0, MalformedObjectError
+		}
+		i += nt
+
+		if data[i] == '}' && len(children) == 0 {
+			i++
+			break
+		}
+
+		if data[i] != '"' {
+			return 0, 0, MalformedObjectError
+		}
+
+		keyEnd, escaped := stringEnd(data[i+1:])
+		if keyEnd == -1 {
+			return 0, 0, MalformedStringError
+		}
+
+		m := member{key: data[i : i+1+keyEnd]}
+		m.sortKey = m.key[1 : len(m.key)-1]
+		if escaped {
+			sortKey, err := Unescape(m.sortKey, nil)
+			if err != nil {
+				return 0, 0, err
+			}
+			m.sortKey = sortKey
+		}
+		i += 1 + keyEnd
+
+		nt = nextToken(data[i:])
+		if nt == -1 || data[i+nt] != ':' {
+			return 0, 0, MalformedObjectError
+		}
+		i += nt + 1
+
+		value, end, err := c.value(data, i)
+		if err != nil {
+			return 0, 0, err
+		}
+		m.value = value
+		i = end
+
+		c.members = append(c.members, m)
+		children = append(children, len(c.members)-1)
+
+		nt = nextToken(data[i:])
+		if nt == -1 {
+			return 0, 0, MalformedObjectError
+		}
+		i += nt
+
+		if data[i] == '}' {
+			i++
+			break
+		}
+		if data[i] != ',' {
+			return 0, 0, MalformedObjectError
+		}
+		i++
+	}
+
+	sort.SliceStable(children, func(a, b int) bool {
+		return bytes.Compare(c.members[children[a]].sortKey, c.members[children[b]].sortKey) < 0
+	})
+
+	return c.add(canonicalNode{kind: '{', children: children}), i, nil
+}
+
+func (c *canonicalizer) array(data []byte, i int) (int, int, error) {
+	var children []int
+
+	for {
+		nt := nextToken(data[i:])
+		if nt == -1 {
+			return 0, 0, MalformedArrayError
+		}
+		i += nt
+
+		if data[i] == ']' && len(children) == 0 {
+			i++
+			break
+		}
+
+		value, end, err := c.value(data, i)
+		if err != nil {
+			return 0, 0, err
+		}
+		children = append(children, value)
+		i = end
+
+		nt = nextToken(data[i:])
+		if nt == -1 {
+			return 0, 0, MalformedArrayError
+		}
+		i += nt
+
+		if data[i] == ']' {
+			i++
+			break
+		}
+		if data[i] != ',' {
+			return 0, 0, MalformedArrayError
+		}
+		i++
+	}
+
+	return c.add(canonicalNode{kind: '[', children: children}), i, nil
+}
+
+// write appends canonical representation of value with index to buf.
+func (c *canonicalizer) write(buf []byte, index int) []byte {
+	v := c.values[index]
+
+	switch v.kind {
+	case '{':
+		buf = append(buf, '{')
+		for n, child := range v.children {
+			if n > 0 {
+				buf = append(buf, ',')
+			}
+			m := c.members[child]
+			buf = append(buf, m.key...)
+			buf = append(buf, ':')
+			buf = c.write(buf, m.value)
+		}
+		return append(buf, '}')
+	case '[':
+		buf = append(buf, '[')
+		for n, child := range v.children {
+			if n > 0 {
+				buf = append(buf, ',')
+			}
+			buf = c.write(buf, child)
+		}
+		return append(buf, ']')
+	}
+
+	return append(buf, v.raw...)
+}
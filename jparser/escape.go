@@ -66,10 +66,16 @@ func decodeUnicodeEscape(in []byte) (rune, int) {
 	} else if r <= basicMultilingualPlaneOffset && !isUTF16EncodedRune(r) {
 		// Valid Unicode escape in Basic Multilingual Plane
 		return r, 6
-	} else if r2, ok := decodeSingleUnicodeEscape(in[6:]); !ok { // Note: previous decodeSingleUnicodeEscape success guarantees at least 6 bytes remain
+	} else if r >= lowSurrogateOffset {
+		// UTF16 "low surrogate" without preceding "high surrogate"
+		return utf8.RuneError, -1
+	} else if len(in) < 8 || in[6] != '\\' || in[7] != 'u' {
+		// UTF16 "high surrogate" is not followed by Unicode escape
+		return utf8.RuneError, -1
+	} else if r2, ok := decodeSingleUnicodeEscape(in[6:]); !ok {
 		// UTF16 "high surrogate" without manditory valid following Unicode escape for the "low surrogate"
 		return utf8.RuneError, -1
-	} else if r2 < lowSurrogateOffset {
+	} else if r2 < lowSurrogateOffset || r2 > basicMultilingualPlaneReservedOffset {
 		// Invalid UTF16 "low surrogate"
 		return utf8.RuneError, -1
 	} else {
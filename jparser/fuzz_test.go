@@ -0,0 +1,269 @@
+package jparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// seeds adds conformance corpus and requests to fuzz seed corpus.
+func seeds(f *testing.F) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+
+	f.Add(arr)
+	f.Add(request)
+}
+
+func FuzzValidateBytes(f *testing.F) {
+	seeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		err := ValidateBytes(data)
+
+		// encoding/json replaces invalid UTF-8, validator does not check it
+		if !utf8.Valid(data) {
+			return
+		}
+
+		if valid := json.Valid(data); valid != (err == nil) {
+			t.Errorf("Unexpected result for %q. Expected valid %v. Got %v", data, valid, err)
+		}
+	})
+}
+
+func FuzzParse(f *testing.F) {
+	seeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, p := range []*JParser{Parse(data), ParseStrict(data)} {
+			checkParse(t, data, p)
+		}
+	})
+}
+
+// checkParse compares parsed request with encoding/json.
+func checkParse(t *testing.T, data []byte, p *JParser) {
+	if !utf8.Valid(data) {
+		return
+	}
+
+	if !json.Valid(data) {
+		if p.Error() != ErrParseJSON {
+			t.Errorf("Unexpected error for %q. Expected %v. Got %v", data, ErrParseJSON, p.Error())
+		}
+		return
+	}
+
+	// null is decoded to nil map without error
+	var request map[string]json.RawMessage
+	if err := json.Unmarshal(data, &request); err != nil || request == nil {
+		if p.Error() != ErrNotObject {
+			t.Errorf("Unexpected error for %q. Expected %v. Got %v", data, ErrNotObject, p.Error())
+		}
+		return
+	}
+
+	if p.Error() == ErrParseJSON || p.Error() == ErrNotObject {
+		t.Errorf("Unexpected error for %q: %v", data, p.Error())
+		return
+	}
+
+	if p.Error() != nil {
+		return
+	}
+
+	// last duplicated member wins in encoding/json, first one in strict mode
+	if p.strict {
+		return
+	}
+
+	compareMember(t, data, "id", p.ID, request)
+	compareMember(t, data, "params", p.Params, request)
+
+	if p.MethodType == String {
+		compareMember(t, data, "method", quote(p.Method), request)
+	}
+	if p.VersionType == String {
+		compareMember(t, data, "jsonrpc", quote(p.Version), request)
+	}
+}
+
+func compareMember(t *testing.T, data []byte, name string, value []byte, request map[string]json.RawMessage) {
+	expected, ok := request[name]
+	if !ok && value == nil {
+		return
+	}
+
+	if !bytes.Equal(bytes.TrimSpace(expected), value) {
+		t.Errorf("Unexpected %v for %q. Expected %s. Got %s", name, data, expected, value)
+	}
+}
+
+func quote(s []byte) []byte {
+	return []byte(`"` + string(s) + `"`)
+}
+
+func FuzzUnescape(f *testing.F) {
+	for _, s := range []string{``, `abc`, `\n\t\"`, `\u0041\u00e9`, `\ud83d\ude00`, `\ud83d`, `\u12`, `\x`, `a\\b\/`} {
+		f.Add([]byte(s))
+	}
+
+	f.Fuzz(func(t *testing.T, in []byte) {
+		// quotes and control chars are rejected by validator, not by Unescape
+		if !utf8.Valid(in) || bytes.IndexByte(in, '"') != -1 || bytes.IndexFunc(in, func(r rune) bool { return r < 0x20 }) != -1 {
+			return
+		}
+
+		out, err := Unescape(in, nil)
+
+		var expected string
+		if jsonErr := json.Unmarshal(quote(in), &expected); jsonErr != nil {
+			if err == nil {
+				t.Errorf("Expected error for %q. Got %q", in, out)
+			}
+			return
+		}
+
+		if err != nil {
+			// lone UTF-16 surrogates are rejected, encoding/json replaces them with U+FFFD
+			if !strings.ContainsRune(expected, utf8.RuneError) {
+				t.Errorf("Received unexpected error for %q:\n%+v", in, err)
+			}
+			return
+		}
+
+		if string(out) != expected {
+			t.Errorf("Unexpected result for %q. Expected %q. Got %q", in, expected, out)
+		}
+	})
+}
+
+func FuzzCanonicalize(f *testing.F) {
+	seeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		out, err := Canonicalize(data)
+		if err != nil || !utf8.Valid(data) || ValidateBytes(data) != nil {
+			return
+		}
+
+		if !json.Valid(out) {
+			t.Errorf("Invalid canonical JSON for %q: %q", data, out)
+		}
+
+		again, err := Canonicalize(out)
+		if err != nil || !bytes.Equal(out, again) {
+			t.Errorf("Canonical JSON is not stable for %q: %q, %q", data, out, again)
+		}
+	})
+}
+
+func FuzzArrayElement(f *testing.F) {
+	seeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if !IsArray(data) {
+			return
+		}
+
+		n := ArrayLength(data)
+		for i := 0; i < n; i++ {
+			if ArrayElement(data, i) == nil {
+				t.Errorf("Missing element %d of %q", i, data)
+			}
+		}
+	})
+}
+
+// TestConformance checks JSON test vectors: y_ files must be accepted, n_ files must be rejected, i_ files are
+// implementation defined and must not crash parser.
+func TestConformance(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) == 0 {
+		t.Fatal("Conformance corpus is empty")
+	}
+
+	for _, file := range files {
+		name := filepath.Base(file)
+
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = ValidateBytes(data)
+			p := Parse(data)
+
+			switch {
+			case strings.HasPrefix(name, "y_"):
+				if err != nil {
+					t.Errorf("Received unexpected error:\n%+v", err)
+				}
+				if p.Error() == ErrParseJSON {
+					t.Errorf("Received unexpected parse error")
+				}
+			case strings.HasPrefix(name, "n_"):
+				if err == nil {
+					t.Errorf("Expected error for %q", data)
+				}
+				if p.Error() != ErrParseJSON {
+					t.Errorf("Unexpected error. Expected %v. Got %v", ErrParseJSON, p.Error())
+				}
+			}
+		})
+	}
+}
+
+func FuzzSplitBatch(f *testing.F) {
+	seeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		elements, err := SplitBatch(data)
+
+		if !utf8.Valid(data) {
+			return
+		}
+
+		var batch []json.RawMessage
+		jsonErr := json.Unmarshal(data, &batch)
+
+		switch {
+		case !json.Valid(data):
+			if err != ErrParseJSON {
+				t.Errorf("Unexpected error for %q. Expected %v. Got %v", data, ErrParseJSON, err)
+			}
+		case jsonErr != nil || batch == nil:
+			if err != ErrUnexpectedType {
+				t.Errorf("Unexpected error for %q. Expected %v. Got %v", data, ErrUnexpectedType, err)
+			}
+		case err != nil || len(elements) != len(batch):
+			t.Errorf("Unexpected result for %q. Expected %d elements. Got %d %v", data, len(batch), len(elements), err)
+		default:
+			for i := range batch {
+				if !bytes.Equal(bytes.TrimSpace(batch[i]), elements[i]) {
+					t.Errorf("Unexpected element %d for %q. Expected %s. Got %s", i, data, batch[i], elements[i])
+				}
+			}
+		}
+	})
+}
@@ -1,5 +1,7 @@
 package jparser
 
+import "strings"
+
 type JParser struct {
 	data []byte
 
@@ -15,7 +17,8 @@ type JParser struct {
 	Params     []byte
 	ParamsType ValueType
 
-	err error
+	strict bool
+	err    error
 }
 
 // Parse jsonrpc request data.
@@ -29,11 +32,27 @@ func Parse(data []byte) *JParser {
 	return jParser
 }
 
-// GetId get jsonrpc id as string.
+// ParseStrict parse jsonrpc request data in strict mode. In addition to Parse checks, request must not contain
+// unknown or duplicate members, params must be an object or an array and method must not start with "rpc.".
+// Violation is reported as *StrictError, other fields are parsed anyway.
+func ParseStrict(data []byte) *JParser {
+	jParser := &JParser{
+		data:   data,
+		strict: true,
+	}
+
+	jParser.parse()
+
+	return jParser
+}
+
+// GetId get jsonrpc id as string. String id is unescaped.
 func (j *JParser) GetId() string {
 	if j.IDType == String {
-		id := j.ID[1 : len(j.ID)-1]
-		return string(id)
+		if id, err := ParseString(j.ID); err == nil {
+			return id
+		}
+		return string(j.ID[1 : len(j.ID)-1])
 	}
 	return string(j.ID)
 }
@@ -53,129 +72,176 @@ func (j *JParser) Error() error {
 	return j.err
 }
 
-// parse jsonrpc.
+// parse jsonrpc request in a single pass: data is validated and envelope members are extracted at the same time.
+// Syntax errors are reported as ErrParseJSON right away. Envelope errors, e.g. incorrect member type or strict
+// mode violation, are reported after the whole request is parsed, so other members, e.g. id, are available.
 func (j *JParser) parse() {
 	j.IDType = NotExist
 	j.VersionType = NotExist
 	j.MethodType = NotExist
 	j.ParamsType = NotExist
 
-	level := 0
-	i := 0
-	ln := len(j.data)
+	s := skipWS(b2s(j.data))
+	if len(s) == 0 {
+		j.err = ErrParseJSON
+		return
+	}
+
+	if s[0] != '{' {
+		// request must be an object, but it must be a valid JSON anyway
+		tail, err := validateValue(s, 0)
+		if err != nil || len(skipWS(tail)) > 0 {
+			j.err = ErrParseJSON
+			return
+		}
+
+		j.err = ErrNotObject
+		return
+	}
+
+	var envelopeErr error
+
+	tail, err := j.parseObject(s[1:], &envelopeErr)
+	if err != nil || len(skipWS(tail)) > 0 {
+		j.err = ErrParseJSON
+		return
+	}
+
+	if j.strict && envelopeErr == nil {
+		if strictErr := j.checkStrict(); strictErr != nil {
+			envelopeErr = strictErr
+		}
+	}
+
+	j.err = envelopeErr
+}
+
+// parseObject validates request object members and extracts envelope members. The first envelope error is
+// stored to envelopeErr.
+func (j *JParser) parseObject(s string, envelopeErr *error) (string, error) {
+	s = skipWS(s)
+	if len(s) == 0 {
+		return s, MalformedObjectError
+	}
+	if s[0] == '}' {
+		return s[1:], nil
+	}
+
 	var stackbuf [unescapeStackBufSize]byte
+	var seen [4]bool
 
-	for i < ln {
-		switch j.data[i] {
-		case '"':
-			i++
-			keyBegin := i
+	for {
+		var err error
 
-			strEnd, keyEscaped := stringEnd(j.data[i:])
-			if strEnd == -1 {
-				j.err = ErrParseJSON
-				return
-			}
-			i += strEnd
-			keyEnd := i - 1
+		s = skipWS(s)
+		if len(s) == 0 || s[0] != '"' {
+			return s, MalformedObjectError
+		}
 
-			valueOffset := nextToken(j.data[i:])
-			if valueOffset == -1 {
-				j.err = ErrParseJSON
-				return
+		var key string
+		key, s, err = validateKey(s[1:])
+		if err != nil {
+			return s, err
+		}
+		for i := 0; i < len(key); i++ {
+			if key[i] < 0x20 {
+				return s, MalformedStringError
 			}
+		}
 
-			i += valueOffset
-
-			// if string is a key
-			if j.data[i] == ':' {
-				if level < 1 {
-					j.err = ErrParseJSON
-					return
-				}
-
-				key := j.data[keyBegin:keyEnd]
-
-				// for unescape: if there are no escape sequences, this is cheap; if there are, it is a
-				// bit more expensive, but causes no allocations unless len(key) > unescapeStackBufSize
-				var keyUnesc []byte
-				if !keyEscaped {
-					keyUnesc = key
-				} else if ku, err := Unescape(key, stackbuf[:]); err != nil {
-					j.err = ErrParseJSON
-					return
-				} else {
-					keyUnesc = ku
-				}
-
-				if level == 1 {
-					i++
-
-					nt := nextToken(j.data[i:])
-					if nt == -1 {
-						j.err = ErrParseJSON
-						return
-					}
-
-					i += nt
-					value, dataType, endOffset, err := getType(j.data[i:])
-					if err != nil {
-						j.err = ErrParseJSON
-						return
-					}
-
-					if string(keyUnesc) == "id" {
-						if dataType == String || dataType == Number || dataType == Null {
-							j.ID = value
-							j.IDType = dataType
-						} else {
-							j.err = ErrIncorrectFieldType
-							return
-						}
-					} else if string(keyUnesc) == "jsonrpc" {
-						if dataType != String {
-							j.err = ErrIncorrectFieldType
-							return
-						}
-						j.Version = value[1 : len(value)-1]
-						j.VersionType = dataType
-					} else if string(keyUnesc) == "method" {
-						if dataType != String {
-							j.err = ErrIncorrectFieldType
-							return
-						}
-						j.Method = value[1 : len(value)-1]
-						j.MethodType = dataType
-					} else if string(keyUnesc) == "params" {
-						j.Params = value
-						j.ParamsType = dataType
-					}
-
-					i += endOffset
-				} else {
-					j.err = ErrParseJSON
-					return
-				}
-			} else {
-				i--
-			}
-		case '{':
-			level++
-			// skip all, except first level
-			if level > 1 {
-				j.err = ErrParseJSON
-				return
+		s = skipWS(s)
+		if len(s) == 0 || s[0] != ':' {
+			return s, MalformedObjectError
+		}
+
+		s = skipWS(s[1:])
+		valueBegin := len(j.data) - len(s)
+		s, err = validateValue(s, 1)
+		if err != nil {
+			return s, err
+		}
+		value := j.data[valueBegin : len(j.data)-len(s)]
+
+		// for unescape: if there are no escape sequences, this is cheap; if there are, it is a
+		// bit more expensive, but causes no allocations unless len(key) > unescapeStackBufSize
+		keyUnesc := s2b(key)
+		if strings.IndexByte(key, '\\') != -1 {
+			if keyUnesc, err = Unescape(keyUnesc, stackbuf[:]); err != nil {
+				return s, err
 			}
-		case '}':
-			level--
-		case '[':
-			j.err = ErrParseJSON
-			return
-		case ':':
-			j.err = ErrParseJSON
-			return
 		}
 
-		i++
+		if err := j.setMember(keyUnesc, value, &seen); err != nil && *envelopeErr == nil {
+			*envelopeErr = err
+		}
+
+		s = skipWS(s)
+		if len(s) == 0 {
+			return s, MalformedObjectError
+		}
+		if s[0] == ',' {
+			s = s[1:]
+			continue
+		}
+		if s[0] == '}' {
+			return s[1:], nil
+		}
+		return s, MalformedObjectError
+	}
+}
+
+// setMember store envelope member value. Unknown members are ignored in lenient mode.
+func (j *JParser) setMember(key []byte, value []byte, seen *[4]bool) error {
+	if j.strict {
+		if err := checkMember(key, seen); err != nil {
+			// duplicated member does not overwrite first value
+			return err
+		}
 	}
+
+	dataType := valueType(value)
+
+	switch string(key) {
+	case "id":
+		if dataType != String && dataType != Number && dataType != Null {
+			return ErrIncorrectFieldType
+		}
+		j.ID = value
+		j.IDType = dataType
+	case "jsonrpc":
+		if dataType != String {
+			return ErrIncorrectFieldType
+		}
+		j.Version = value[1 : len(value)-1]
+		j.VersionType = dataType
+	case "method":
+		if dataType != String {
+			return ErrIncorrectFieldType
+		}
+		j.Method = value[1 : len(value)-1]
+		j.MethodType = dataType
+	case "params":
+		j.Params = value
+		j.ParamsType = dataType
+	}
+
+	return nil
+}
+
+// valueType returns type of valid JSON value.
+func valueType(value []byte) ValueType {
+	switch value[0] {
+	case '"':
+		return String
+	case '{':
+		return Object
+	case '[':
+		return Array
+	case 't', 'f':
+		return Boolean
+	case 'n':
+		return Null
+	}
+
+	return Number
 }
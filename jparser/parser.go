@@ -8,6 +8,7 @@ import (
 var (
 	ErrParseJSON               = errors.New("parse error")
 	ErrIncorrectFieldType      = errors.New("incorrect field type")
+	ErrNotObject               = errors.New("request is not an object")
 	UnknownValueTypeError      = errors.New("unknown value type")
 	MalformedStringError       = errors.New("value is string, but can't find closing '\"' symbol")
 	MalformedArrayError        = errors.New("value is array, but can't find closing ']' symbol")
@@ -1,7 +1,10 @@
 package jparser
 
 import (
+	"bytes"
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -57,3 +60,458 @@ func TestIsArray(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestCanonicalize(t *testing.T) {
+	var tc = []struct {
+		in, out string
+	}{
+		{in: ` {"b" : [1, 2 ,{"d":null, "c":true}], "a":"x y"} `, out: `{"a":"x y","b":[1,2,{"c":true,"d":null}]}`},
+		{in: `{"b":1,"a":2}`, out: `{"a":2,"b":1}`},
+		{in: `[ ]`, out: `[]`},
+		{in: `{ }`, out: `{}`},
+		{in: `-1.5e3`, out: `-1.5e3`},
+	}
+
+	for _, c := range tc {
+		out, err := Canonicalize([]byte(c.in))
+		if err != nil {
+			t.Errorf("Received unexpected error:\n%+v", err)
+			t.FailNow()
+		}
+
+		if string(out) != c.out {
+			t.Errorf("Unexpected result. Expected %v. Got %v", c.out, string(out))
+		}
+	}
+
+	for _, in := range []string{`{"a"}`, `[1 2]`, `{"a":1,}`, `[1,`} {
+		if _, err := Canonicalize([]byte(in)); err == nil {
+			t.Errorf("Expected error for %v", in)
+		}
+	}
+}
+
+// deepObject returns object nested depth levels, with unsorted keys and payload of size bytes at the bottom.
+func deepObject(depth, size int) []byte {
+	var b bytes.Buffer
+
+	for i := 0; i < depth; i++ {
+		b.WriteString(`{"z": 1, "a": `)
+	}
+	b.WriteString(`"` + strings.Repeat("x", size) + `"`)
+	for i := 0; i < depth; i++ {
+		b.WriteString(`}`)
+	}
+
+	return b.Bytes()
+}
+
+func TestCanonicalizeDeep(t *testing.T) {
+	out, err := Canonicalize(deepObject(3, 1))
+	if err != nil {
+		t.Errorf("Received unexpected error:\n%+v", err)
+	}
+
+	expected := `{"a":{"a":{"a":"x","z":1},"z":1},"z":1}`
+	if string(out) != expected {
+		t.Errorf("Unexpected result. Expected %v. Got %v", expected, string(out))
+	}
+}
+
+func BenchmarkCanonicalize(b *testing.B) {
+	b.Run("Request", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			_, _ = Canonicalize(request)
+		}
+	})
+
+	// cost must not grow with nesting depth multiplied by data size
+	b.Run("Deep", func(b *testing.B) {
+		data := deepObject(4000, 1<<20)
+
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			_, _ = Canonicalize(data)
+		}
+	})
+}
+
+func TestParseStrict(t *testing.T) {
+	var tc = []struct {
+		name, in, rule string
+	}{
+		{name: "OK", in: `{"jsonrpc":"2.0","method":"sum","params":[1, 2],"id":1}`},
+		{name: "NoParams", in: `{"jsonrpc":"2.0","method":"sum","id":1}`},
+		{name: "UnknownMember", in: `{"jsonrpc":"2.0","method":"sum","extra":true,"id":1}`, rule: RuleUnknownMember},
+		{name: "DuplicateID", in: `{"jsonrpc":"2.0","method":"sum","id":1,"id":2}`, rule: RuleDuplicateMember},
+		{name: "DuplicateEscaped", in: `{"jsonrpc":"2.0","method":"sum","\u006dethod":"sub","id":1}`, rule: RuleDuplicateMember},
+		{name: "ParamsType", in: `{"jsonrpc":"2.0","method":"sum","params":"1, 2","id":1}`, rule: RuleParamsType},
+		{name: "ReservedMethod", in: `{"jsonrpc":"2.0","method":"rpc.discover","id":1}`, rule: RuleReservedMethod},
+		{name: "ReservedEscaped", in: `{"jsonrpc":"2.0","method":"\u0072pc.discover","id":1}`, rule: RuleReservedMethod},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			p := ParseStrict([]byte(c.in))
+
+			if c.rule == "" {
+				if p.Error() != nil {
+					t.Errorf("Received unexpected error:\n%+v", p.Error())
+				}
+				return
+			}
+
+			strictErr, ok := p.Error().(*StrictError)
+			if !ok || strictErr.Rule != c.rule {
+				t.Errorf("Unexpected result. Expected %v. Got %v", c.rule, p.Error())
+			}
+
+			if string(p.ID) != "1" {
+				t.Errorf("Unexpected id. Expected %v. Got %v", 1, string(p.ID))
+			}
+
+			if lenient := Parse([]byte(c.in)); lenient.Error() != nil {
+				t.Errorf("Received unexpected error in lenient mode:\n%+v", lenient.Error())
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	var tc = []struct {
+		name, in   string
+		err        error
+		id, method string
+		params     string
+		idType     ValueType
+		paramsType ValueType
+	}{
+		{
+			name: "NestedParams", in: `{"jsonrpc":"2.0","method":"sum","params":{"a":[1,{"b":{"c":[]}}],"d":"}"},"id":1}`,
+			id: "1", idType: Number, method: "sum", params: `{"a":[1,{"b":{"c":[]}}],"d":"}"}`, paramsType: Object,
+		},
+		{
+			name: "UnknownMembers", in: ` {"extra":{"id":2,"x":[{"method":"sub"}]}, "jsonrpc":"2.0","method":"sum","id":"a\"b"} `,
+			id: `"a\"b"`, idType: String, method: "sum", paramsType: NotExist,
+		},
+		{
+			name: "EscapedKey", in: `{"jsonrpc":"2.0","method":"sum","params":[],"id":null}`,
+			id: "null", idType: Null, method: "sum", params: `[]`, paramsType: Array,
+		},
+		{name: "ObjectID", in: `{"jsonrpc":"2.0","method":"sum","id":{"a":1}}`, err: ErrIncorrectFieldType, idType: NotExist},
+		{name: "NumberMethod", in: `{"jsonrpc":"2.0","method":1,"id":1}`, err: ErrIncorrectFieldType, id: "1", idType: Number},
+		{name: "NotObject", in: `[1]`, err: ErrNotObject},
+		{name: "Number", in: `1`, err: ErrNotObject},
+		{name: "Empty", in: ` `, err: ErrParseJSON},
+		{name: "TrailingData", in: `{"jsonrpc":"2.0","method":"sum","id":1} 1`, err: ErrParseJSON},
+		{name: "InvalidParams", in: `{"jsonrpc":"2.0","method":"sum","params":[1,],"id":1}`, err: ErrParseJSON},
+		{name: "InvalidNested", in: `{"jsonrpc":"2.0","method":"sum","params":{"a":tru},"id":1}`, err: ErrParseJSON},
+		{name: "Unclosed", in: `{"jsonrpc":"2.0","method":"sum"`, err: ErrParseJSON},
+		{name: "ControlChar", in: "{\"jsonrpc\":\"2.0\",\"method\":\"s\x01um\",\"id\":1}", err: ErrParseJSON},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			p := Parse([]byte(c.in))
+
+			if p.Error() != c.err {
+				t.Errorf("Unexpected error. Expected %v. Got %v", c.err, p.Error())
+			}
+
+			if c.err == ErrParseJSON || c.err == ErrNotObject {
+				return
+			}
+
+			if string(p.ID) != c.id || p.IDType != c.idType {
+				t.Errorf("Unexpected id. Expected %v %v. Got %v %v", c.id, c.idType, string(p.ID), p.IDType)
+			}
+
+			if c.err != nil {
+				return
+			}
+
+			if p.GetMethod() != c.method || p.GetVersion() != "2.0" {
+				t.Errorf("Unexpected method. Expected %v. Got %v", c.method, p.GetMethod())
+			}
+
+			if string(p.Params) != c.params || p.ParamsType != c.paramsType {
+				t.Errorf("Unexpected params. Expected %v %v. Got %v %v", c.params, c.paramsType, string(p.Params), p.ParamsType)
+			}
+		})
+	}
+}
+
+var request = []byte(`{"jsonrpc":"2.0","method":"user.update","params":{"id":42,"name":"John \"Johnny\" Doe","tags":["a","b",{"nested":[1,2,3,{"deep":true}]}],"address":{"city":"Berlin","zip":"10115"}},"id":"req-1"}`)
+
+func BenchmarkParse(b *testing.B) {
+	b.Run("SinglePass", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			Parse(request)
+		}
+	})
+
+	b.Run("ValidateAndParse", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			if ValidateBytes(request) == nil {
+				Parse(request)
+			}
+		}
+	})
+}
+
+var params = []byte(`{"user":{"id":42,"name":"J\"D","tags":["a","b"]},"items":[{"id":1},{"id":2,"price":9.5}],"flag":true, "none": null}`)
+
+func TestGet(t *testing.T) {
+	var tc = []struct {
+		keys     []string
+		value    string
+		dataType ValueType
+		err      error
+	}{
+		{keys: []string{"user", "id"}, value: `42`, dataType: Number},
+		{keys: []string{"user", "name"}, value: `"J\"D"`, dataType: String},
+		{keys: []string{"user", "tags", "[1]"}, value: `"b"`, dataType: String},
+		{keys: []string{"items", "[1]", "price"}, value: `9.5`, dataType: Number},
+		{keys: []string{"items", "[0]"}, value: `{"id":1}`, dataType: Object},
+		{keys: []string{"flag"}, value: `true`, dataType: Boolean},
+		{keys: []string{"none"}, value: `null`, dataType: Null},
+		{keys: []string{}, value: string(params), dataType: Object},
+		{keys: []string{"user", "age"}, err: KeyPathNotFoundError},
+		{keys: []string{"items", "[2]"}, err: KeyPathNotFoundError},
+		{keys: []string{"items", "0"}, err: KeyPathNotFoundError},
+		{keys: []string{"user", "id", "x"}, err: KeyPathNotFoundError},
+	}
+
+	for _, c := range tc {
+		value, dataType, err := Get(params, c.keys...)
+		if err != c.err {
+			t.Errorf("Unexpected error for %v. Expected %v. Got %v", c.keys, c.err, err)
+			continue
+		}
+
+		if string(value) != c.value || dataType != c.dataType {
+			t.Errorf("Unexpected result for %v. Expected %v %v. Got %v %v", c.keys, c.value, c.dataType, string(value), dataType)
+		}
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _, _ = Get(params, "items", "[1]", "price")
+	})
+	if allocs != 0 {
+		t.Errorf("Unexpected allocations. Expected %v. Got %v", 0, allocs)
+	}
+}
+
+func TestArrayEach(t *testing.T) {
+	var values []string
+	err := ArrayEach([]byte(` [1, "a", {"b":[2]}, [] ] `), func(value []byte, dataType ValueType) error {
+		values = append(values, string(value)+":"+dataType.String())
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Received unexpected error:\n%+v", err)
+	}
+
+	expected := []string{"1:number", `"a":string`, `{"b":[2]}:object`, "[]:array"}
+	if !reflect.DeepEqual(expected, values) {
+		t.Errorf("Unexpected result. Expected %v. Got %v", expected, values)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = ArrayEach([]byte(`[1, 2, 3]`), func(value []byte, dataType ValueType) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("Iteration was not stopped")
+	}
+
+	if err = ArrayEach([]byte(`{}`), func([]byte, ValueType) error { return nil }); err != ErrUnexpectedType {
+		t.Errorf("Unexpected error. Expected %v. Got %v", ErrUnexpectedType, err)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = ArrayEach(arr, func([]byte, ValueType) error { return nil })
+	})
+	if allocs != 0 {
+		t.Errorf("Unexpected allocations. Expected %v. Got %v", 0, allocs)
+	}
+}
+
+func TestObjectEach(t *testing.T) {
+	var members []string
+	err := ObjectEach(params, func(key []byte, value []byte, dataType ValueType) error {
+		members = append(members, string(key)+":"+dataType.String())
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Received unexpected error:\n%+v", err)
+	}
+
+	expected := []string{"user:object", "items:array", "flag:boolean", "none:null"}
+	if !reflect.DeepEqual(expected, members) {
+		t.Errorf("Unexpected result. Expected %v. Got %v", expected, members)
+	}
+
+	if err = ObjectEach([]byte(`{"a":1,}`), func([]byte, []byte, ValueType) error { return nil }); err == nil {
+		t.Errorf("Expected error for malformed object")
+	}
+
+	escaped := []byte(`{"a\u0062":1,"c":2}`)
+	members = nil
+	err = ObjectEach(escaped, func(key []byte, value []byte, dataType ValueType) error {
+		members = append(members, string(key))
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Received unexpected error:\n%+v", err)
+	}
+
+	expected = []string{"ab", "c"}
+	if !reflect.DeepEqual(expected, members) {
+		t.Errorf("Unexpected result. Expected %v. Got %v", expected, members)
+	}
+
+	for _, data := range [][]byte{params, escaped} {
+		allocs := testing.AllocsPerRun(100, func() {
+			_ = ObjectEach(data, func([]byte, []byte, ValueType) error { return nil })
+		})
+		if allocs != 0 {
+			t.Errorf("Unexpected allocations for %s. Expected %v. Got %v", data, 0, allocs)
+		}
+	}
+}
+
+func TestSplitBatch(t *testing.T) {
+	var tc = []struct {
+		in       string
+		elements []string
+		err      error
+	}{
+		{in: ` [{"id":1}, 2 , "a"] `, elements: []string{`{"id":1}`, `2`, `"a"`}},
+		{in: `[]`, elements: nil},
+		{in: `{"id":1}`, err: ErrUnexpectedType},
+		{in: `1`, err: ErrUnexpectedType},
+		{in: `[{"id":1},]`, err: ErrParseJSON},
+		{in: `[{"id":1}] 1`, err: ErrParseJSON},
+		{in: `[{"id":1}`, err: ErrParseJSON},
+		{in: `{"id":`, err: ErrParseJSON},
+		{in: ``, err: ErrParseJSON},
+	}
+
+	for _, c := range tc {
+		elements, err := SplitBatch([]byte(c.in))
+		if err != c.err {
+			t.Errorf("Unexpected error for %q. Expected %v. Got %v", c.in, c.err, err)
+			continue
+		}
+
+		var values []string
+		for _, e := range elements {
+			values = append(values, string(e))
+		}
+
+		if !reflect.DeepEqual(c.elements, values) {
+			t.Errorf("Unexpected result for %q. Expected %v. Got %v", c.in, c.elements, values)
+		}
+	}
+}
+
+func TestGetId(t *testing.T) {
+	var tc = []struct {
+		in, id string
+	}{
+		{in: `{"id":"a\"b\u00e9"}`, id: `a"bé`},
+		{in: `{"id":"\ud800"}`, id: `\ud800`},
+		{in: `{"id":1.5}`, id: `1.5`},
+		{in: `{"id":null}`, id: `null`},
+	}
+
+	for _, c := range tc {
+		if id := Parse([]byte(c.in)).GetId(); id != c.id {
+			t.Errorf("Unexpected result. Expected %v. Got %v", c.id, id)
+		}
+	}
+}
+
+func BenchmarkSplitBatch(b *testing.B) {
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = SplitBatch(arr)
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	if s, err := ParseString([]byte(`"J\"Dé"`)); err != nil || s != `J"Dé` {
+		t.Errorf("Unexpected result. Expected %v. Got %v %v", `J"Dé`, s, err)
+	}
+
+	if _, err := ParseString([]byte(`1`)); err != ErrUnexpectedType {
+		t.Errorf("Unexpected error. Expected %v. Got %v", ErrUnexpectedType, err)
+	}
+
+	if _, err := ParseString([]byte(`"\ud800"`)); err != MalformedStringEscapeError {
+		t.Errorf("Unexpected error. Expected %v. Got %v", MalformedStringEscapeError, err)
+	}
+
+	var ints = []struct {
+		in  string
+		n   int64
+		err error
+	}{
+		{in: `-42`, n: -42},
+		{in: `0`, n: 0},
+		{in: `1.5`, err: ErrUnexpectedType},
+		{in: `1e3`, err: ErrUnexpectedType},
+		{in: `9223372036854775808`, err: OverflowIntegerError},
+		{in: `"1"`, err: ErrUnexpectedType},
+		{in: `0x10`, err: ErrUnexpectedType},
+	}
+
+	for _, c := range ints {
+		if n, err := ParseInt([]byte(c.in)); n != c.n || err != c.err {
+			t.Errorf("Unexpected result for %v. Expected %v %v. Got %v %v", c.in, c.n, c.err, n, err)
+		}
+	}
+
+	var floats = []struct {
+		in  string
+		f   float64
+		err error
+	}{
+		{in: `-1.5e2`, f: -150},
+		{in: `3`, f: 3},
+		{in: `NaN`, err: ErrUnexpectedType},
+		{in: `inf`, err: ErrUnexpectedType},
+		{in: `true`, err: ErrUnexpectedType},
+	}
+
+	for _, c := range floats {
+		if f, err := ParseFloat([]byte(c.in)); f != c.f || err != c.err {
+			t.Errorf("Unexpected result for %v. Expected %v %v. Got %v %v", c.in, c.f, c.err, f, err)
+		}
+	}
+
+	if b, err := ParseBool([]byte(`true`)); err != nil || !b {
+		t.Errorf("Unexpected result. Expected %v. Got %v %v", true, b, err)
+	}
+
+	if _, err := ParseBool([]byte(`1`)); err != ErrUnexpectedType {
+		t.Errorf("Unexpected error. Expected %v. Got %v", ErrUnexpectedType, err)
+	}
+}
@@ -0,0 +1,302 @@
+package jparser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	KeyPathNotFoundError = errors.New("key path not found")
+	ErrUnexpectedType    = errors.New("unexpected value type")
+)
+
+// Get returns value by path of object keys and array indexes, e.g. Get(data, "users", "[0]", "name"). Array
+// indexes are written in square brackets. KeyPathNotFoundError is returned if path does not exist. Returned
+// value refers to data.
+func Get(data []byte, keys ...string) ([]byte, ValueType, error) {
+	s := skipWS(b2s(data))
+
+	if len(keys) == 0 {
+		tail, err := validateValue(s, 0)
+		if err != nil || len(skipWS(tail)) > 0 {
+			return nil, NotExist, ErrParseJSON
+		}
+		s = s[:len(s)-len(tail)]
+	}
+
+	for _, key := range keys {
+		if len(s) == 0 {
+			return nil, NotExist, KeyPathNotFoundError
+		}
+
+		var err error
+
+		switch s[0] {
+		case '{':
+			s, err = objectMember(s[1:], key)
+		case '[':
+			index, ok := arrayIndex(key)
+			if !ok {
+				return nil, NotExist, KeyPathNotFoundError
+			}
+			s, err = arrayItem(s[1:], index)
+		default:
+			return nil, NotExist, KeyPathNotFoundError
+		}
+
+		if err != nil {
+			return nil, NotExist, err
+		}
+	}
+
+	value := subslice(data, s)
+
+	return value, valueType(value), nil
+}
+
+// arrayIndex parses array index path segment, e.g. "[2]".
+func arrayIndex(key string) (int, bool) {
+	if len(key) < 3 || key[0] != '[' || key[len(key)-1] != ']' {
+		return 0, false
+	}
+
+	index, err := strconv.Atoi(key[1 : len(key)-1])
+	if err != nil || index < 0 {
+		return 0, false
+	}
+
+	return index, true
+}
+
+// objectMember returns value of object member with key. Data starts after '{'.
+func objectMember(s string, key string) (string, error) {
+	var stackbuf [unescapeStackBufSize]byte
+	var member string
+	found := false
+
+	err := eachMember(s, func(rawKey string, value string) (bool, error) {
+		if strings.IndexByte(rawKey, '\\') == -1 {
+			found = rawKey == key
+		} else if k, err := Unescape(s2b(rawKey), stackbuf[:]); err != nil {
+			return false, err
+		} else {
+			found = string(k) == key
+		}
+
+		if found {
+			member = value
+		}
+
+		return !found, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !found {
+		return "", KeyPathNotFoundError
+	}
+
+	return member, nil
+}
+
+// arrayItem returns array item with index. Data starts after '['.
+func arrayItem(s string, index int) (string, error) {
+	var item string
+	i := 0
+	found := false
+
+	_, err := eachItem(s, func(value string) (bool, error) {
+		if i == index {
+			item, found = value, true
+			return false, nil
+		}
+		i++
+
+		return true, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !found {
+		return "", KeyPathNotFoundError
+	}
+
+	return item, nil
+}
+
+// eachMember calls f for every object member with raw key and value, until f returns false. Data starts
+// after '{', values are validated as nested into the object.
+func eachMember(s string, f func(key string, value string) (bool, error)) error {
+	s = skipWS(s)
+	if len(s) == 0 {
+		return MalformedObjectError
+	}
+	if s[0] == '}' {
+		return nil
+	}
+
+	for {
+		s = skipWS(s)
+		if len(s) == 0 || s[0] != '"' {
+			return MalformedObjectError
+		}
+
+		key, tail, err := validateKey(s[1:])
+		if err != nil {
+			return MalformedStringError
+		}
+
+		s = skipWS(tail)
+		if len(s) == 0 || s[0] != ':' {
+			return MalformedObjectError
+		}
+		s = skipWS(s[1:])
+
+		tail, err = validateValue(s, 1)
+		if err != nil {
+			return MalformedValueError
+		}
+
+		next, err := f(key, s[:len(s)-len(tail)])
+		if err != nil || !next {
+			return err
+		}
+
+		s = skipWS(tail)
+		if len(s) == 0 {
+			return MalformedObjectError
+		}
+		if s[0] == '}' {
+			return nil
+		}
+		if s[0] != ',' {
+			return MalformedObjectError
+		}
+		s = s[1:]
+	}
+}
+
+// eachItem calls f for every array item, until f returns false. Data starts after '['. Tail after ']' is
+// returned if all items are iterated.
+func eachItem(s string, f func(value string) (bool, error)) (string, error) {
+	s = skipWS(s)
+	if len(s) == 0 {
+		return s, MalformedArrayError
+	}
+	if s[0] == ']' {
+		return s[1:], nil
+	}
+
+	for {
+		s = skipWS(s)
+
+		tail, err := validateValue(s, 1)
+		if err != nil {
+			return tail, MalformedValueError
+		}
+
+		next, err := f(s[:len(s)-len(tail)])
+		if err != nil || !next {
+			return tail, err
+		}
+
+		s = skipWS(tail)
+		if len(s) == 0 {
+			return s, MalformedArrayError
+		}
+		if s[0] == ']' {
+			return s[1:], nil
+		}
+		if s[0] != ',' {
+			return s, MalformedArrayError
+		}
+		s = s[1:]
+	}
+}
+
+// ArrayEach calls callback for every array item. Iteration is stopped when callback returns error, which is
+// returned by ArrayEach. ErrUnexpectedType is returned if data is not an array.
+func ArrayEach(data []byte, callback func(value []byte, dataType ValueType) error) error {
+	s := skipWS(b2s(data))
+	if len(s) == 0 || s[0] != '[' {
+		return ErrUnexpectedType
+	}
+
+	_, err := eachItem(s[1:], func(item string) (bool, error) {
+		value := subslice(data, item)
+		return true, callback(value, valueType(value))
+	})
+
+	return err
+}
+
+// SplitBatch validates batch data and splits it to elements in a single pass. ErrUnexpectedType is returned if
+// data is not an array, ErrParseJSON if data is not a valid JSON. Elements refer to data.
+func SplitBatch(data []byte) ([][]byte, error) {
+	s := skipWS(b2s(data))
+	if len(s) == 0 || s[0] != '[' {
+		if err := Validate(s); err != nil {
+			return nil, ErrParseJSON
+		}
+		return nil, ErrUnexpectedType
+	}
+
+	var elements [][]byte
+
+	tail, err := eachItem(s[1:], func(item string) (bool, error) {
+		elements = append(elements, subslice(data, item))
+		return true, nil
+	})
+	if err != nil || len(skipWS(tail)) > 0 {
+		return nil, ErrParseJSON
+	}
+
+	return elements, nil
+}
+
+// keyBufPool keeps buffers for unescaped keys passed to ObjectEach callback. Stack buffer can't be used, because
+// key escapes to callback.
+var keyBufPool = sync.Pool{
+	New: func() interface{} {
+		return new([unescapeStackBufSize]byte)
+	},
+}
+
+// ObjectEach calls callback for every object member with unescaped key. Key must not be modified or retained after
+// callback returns. Iteration is stopped when callback returns error, which is returned by ObjectEach.
+// ErrUnexpectedType is returned if data is not an object.
+func ObjectEach(data []byte, callback func(key []byte, value []byte, dataType ValueType) error) error {
+	s := skipWS(b2s(data))
+	if len(s) == 0 || s[0] != '{' {
+		return ErrUnexpectedType
+	}
+
+	var keyBuf *[unescapeStackBufSize]byte
+	defer func() {
+		if keyBuf != nil {
+			keyBufPool.Put(keyBuf)
+		}
+	}()
+
+	return eachMember(s[1:], func(rawKey string, member string) (bool, error) {
+		// keys without escape sequences are not copied
+		key := s2b(rawKey)
+		if strings.IndexByte(rawKey, '\\') != -1 {
+			if keyBuf == nil {
+				keyBuf = keyBufPool.Get().(*[unescapeStackBufSize]byte)
+			}
+
+			var err error
+			if key, err = Unescape(key, keyBuf[:]); err != nil {
+				return false, err
+			}
+		}
+
+		value := subslice(data, member)
+		return true, callback(key, value, valueType(value))
+	})
+}
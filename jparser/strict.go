@@ -0,0 +1,85 @@
+package jparser
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// Strict mode rules.
+const (
+	// RuleUnknownMember request contains member other than jsonrpc, method, params and id.
+	RuleUnknownMember = "unknown_member"
+	// RuleDuplicateMember request contains member more than once.
+	RuleDuplicateMember = "duplicate_member"
+	// RuleParamsType params is not an object or an array.
+	RuleParamsType = "params_type"
+	// RuleReservedMethod method name starts with "rpc.", which is reserved for rpc-internal methods.
+	RuleReservedMethod = "reserved_method"
+)
+
+// StrictError is a violation of strict mode rule.
+type StrictError struct {
+	Rule string
+	// Member is a request member, which violates rule.
+	Member string
+}
+
+// Error implements error interface.
+func (e *StrictError) Error() string {
+	switch e.Rule {
+	case RuleUnknownMember:
+		return "unknown member " + strconv.Quote(e.Member)
+	case RuleDuplicateMember:
+		return "duplicate member " + strconv.Quote(e.Member)
+	case RuleParamsType:
+		return "params must be an object or an array"
+	case RuleReservedMethod:
+		return `method names starting with "rpc." are reserved`
+	}
+
+	return "strict mode violation"
+}
+
+var members = [...]string{"jsonrpc", "method", "params", "id"}
+
+// checkMember check if top level member is known and is not duplicated.
+func checkMember(key []byte, seen *[4]bool) *StrictError {
+	for i, member := range members {
+		if string(key) != member {
+			continue
+		}
+
+		if seen[i] {
+			return &StrictError{Rule: RuleDuplicateMember, Member: member}
+		}
+		seen[i] = true
+
+		return nil
+	}
+
+	return &StrictError{Rule: RuleUnknownMember, Member: string(key)}
+}
+
+var reservedPrefix = []byte("rpc.")
+
+// checkStrict check parsed members.
+func (j *JParser) checkStrict() *StrictError {
+	if j.ParamsType != NotExist && j.ParamsType != Object && j.ParamsType != Array {
+		return &StrictError{Rule: RuleParamsType, Member: "params"}
+	}
+
+	method := j.Method
+	if bytes.IndexByte(method, '\\') != -1 {
+		unescaped, err := Unescape(method, nil)
+		if err != nil {
+			return nil
+		}
+		method = unescaped
+	}
+
+	if bytes.HasPrefix(method, reservedPrefix) {
+		return &StrictError{Rule: RuleReservedMethod, Member: "method"}
+	}
+
+	return nil
+}
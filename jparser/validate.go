@@ -7,11 +7,14 @@ import (
 	"unsafe"
 )
 
+// maxDepth is a max nesting depth of arrays and objects, the same as in encoding/json.
+const maxDepth = 10000
+
 // Validate validates JSON s.
 func Validate(s string) error {
 	s = skipWS(s)
 
-	tail, err := validateValue(s)
+	tail, err := validateValue(s, 0)
 	if err != nil {
 		return fmt.Errorf("cannot parse JSON: %s; unparsed tail: %q", err, startEndString(tail))
 	}
@@ -27,24 +30,22 @@ func ValidateBytes(b []byte) error {
 	return Validate(b2s(b))
 }
 
-func validateValue(s string) (string, error) {
+// validateValue validates value nested into depth arrays and objects. Errors of nested values are not wrapped,
+// otherwise error message grows with every nesting level.
+func validateValue(s string, depth int) (string, error) {
 	if len(s) == 0 {
 		return s, fmt.Errorf("cannot parse empty string")
 	}
 
-	if s[0] == '{' {
-		tail, err := validateObject(s[1:])
-		if err != nil {
-			return tail, fmt.Errorf("cannot parse object: %s", err)
+	if s[0] == '{' || s[0] == '[' {
+		if depth >= maxDepth {
+			return s, fmt.Errorf("exceeded max depth %d", maxDepth)
 		}
-		return tail, nil
-	}
-	if s[0] == '[' {
-		tail, err := validateArray(s[1:])
-		if err != nil {
-			return tail, fmt.Errorf("cannot parse array: %s", err)
+
+		if s[0] == '{' {
+			return validateObject(s[1:], depth+1)
 		}
-		return tail, nil
+		return validateArray(s[1:], depth+1)
 	}
 	if s[0] == '"' {
 		sv, tail, err := validateString(s[1:])
@@ -61,19 +62,19 @@ func validateValue(s string) (string, error) {
 	}
 	if s[0] == 't' {
 		if len(s) < len("true") || s[:len("true")] != "true" {
-			return s, fmt.Errorf("unexpected value found: %q", s)
+			return s, fmt.Errorf("unexpected value found: %q", startEndString(s))
 		}
 		return s[len("true"):], nil
 	}
 	if s[0] == 'f' {
 		if len(s) < len("false") || s[:len("false")] != "false" {
-			return s, fmt.Errorf("unexpected value found: %q", s)
+			return s, fmt.Errorf("unexpected value found: %q", startEndString(s))
 		}
 		return s[len("false"):], nil
 	}
 	if s[0] == 'n' {
 		if len(s) < len("null") || s[:len("null")] != "null" {
-			return s, fmt.Errorf("unexpected value found: %q", s)
+			return s, fmt.Errorf("unexpected value found: %q", startEndString(s))
 		}
 		return s[len("null"):], nil
 	}
@@ -85,7 +86,7 @@ func validateValue(s string) (string, error) {
 	return tail, nil
 }
 
-func validateArray(s string) (string, error) {
+func validateArray(s string, depth int) (string, error) {
 	s = skipWS(s)
 	if len(s) == 0 {
 		return s, fmt.Errorf("missing ']'")
@@ -98,9 +99,9 @@ func validateArray(s string) (string, error) {
 		var err error
 
 		s = skipWS(s)
-		s, err = validateValue(s)
+		s, err = validateValue(s, depth)
 		if err != nil {
-			return s, fmt.Errorf("cannot parse array value: %s", err)
+			return s, err
 		}
 
 		s = skipWS(s)
@@ -119,7 +120,7 @@ func validateArray(s string) (string, error) {
 	}
 }
 
-func validateObject(s string) (string, error) {
+func validateObject(s string, depth int) (string, error) {
 	s = skipWS(s)
 	if len(s) == 0 {
 		return s, fmt.Errorf("missing '}'")
@@ -156,9 +157,9 @@ func validateObject(s string) (string, error) {
 
 		// Parse value
 		s = skipWS(s)
-		s, err = validateValue(s)
+		s, err = validateValue(s, depth)
 		if err != nil {
-			return s, fmt.Errorf("cannot parse object value: %s", err)
+			return s, err
 		}
 		s = skipWS(s)
 		if len(s) == 0 {
@@ -202,10 +203,12 @@ func validateString(s string) (string, string, error) {
 	if err != nil {
 		return rs, tail, err
 	}
+	// raw string is returned, so callers can scan it for control chars
+	raw := rs
 	for {
 		n := strings.IndexByte(rs, '\\')
 		if n < 0 {
-			return rs, tail, nil
+			return raw, tail, nil
 		}
 		n++
 		if n >= len(rs) {
@@ -332,6 +335,16 @@ func b2s(b []byte) string {
 	return *(*string)(unsafe.Pointer(&b))
 }
 
+func s2b(s string) []byte {
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
+
+// subslice returns data slice, which s refers to. S must be a substring of b2s(data).
+func subslice(data []byte, s string) []byte {
+	begin := int(uintptr(unsafe.Pointer(unsafe.StringData(s))) - uintptr(unsafe.Pointer(unsafe.SliceData(data))))
+	return data[begin : begin+len(s)]
+}
+
 const maxStartEndStringLen = 80
 
 func startEndString(s string) string {
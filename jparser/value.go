@@ -0,0 +1,74 @@
+package jparser
+
+import (
+	"errors"
+	"strconv"
+)
+
+var OverflowIntegerError = errors.New("overflow integer")
+
+// ParseString returns unescaped string from raw JSON string value, e.g. value returned by Get.
+func ParseString(b []byte) (string, error) {
+	if len(b) < 2 || b[0] != '"' || b[len(b)-1] != '"' {
+		return "", ErrUnexpectedType
+	}
+
+	var stackbuf [unescapeStackBufSize]byte
+
+	s, err := Unescape(b[1:len(b)-1], stackbuf[:])
+	if err != nil {
+		return "", MalformedStringEscapeError
+	}
+
+	return string(s), nil
+}
+
+// ParseInt returns integer from raw JSON number value. Numbers with fraction or exponent are not integers.
+func ParseInt(b []byte) (int64, error) {
+	if !isNumber(b) {
+		return 0, ErrUnexpectedType
+	}
+
+	n, err := strconv.ParseInt(b2s(b), 10, 64)
+	if err == nil {
+		return n, nil
+	}
+
+	if err.(*strconv.NumError).Err == strconv.ErrRange {
+		return 0, OverflowIntegerError
+	}
+
+	return 0, ErrUnexpectedType
+}
+
+// ParseFloat returns float from raw JSON number value.
+func ParseFloat(b []byte) (float64, error) {
+	if !isNumber(b) {
+		return 0, ErrUnexpectedType
+	}
+
+	f, err := strconv.ParseFloat(b2s(b), 64)
+	if err != nil {
+		return 0, MalformedValueError
+	}
+
+	return f, nil
+}
+
+// ParseBool returns boolean from raw JSON true or false value.
+func ParseBool(b []byte) (bool, error) {
+	switch string(b) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+
+	return false, ErrUnexpectedType
+}
+
+// isNumber checks that b is a valid JSON number, strconv accepts also hex, inf and nan.
+func isNumber(b []byte) bool {
+	tail, err := validateNumber(b2s(b))
+	return err == nil && len(tail) == 0
+}
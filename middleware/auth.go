@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+// PrincipalKey is a RequestCtx key, which stores authenticated Principal.
+const PrincipalKey = "jsonrpc.principal"
+
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Principal is an authenticated client.
+type Principal struct {
+	Subject string
+	Scopes  []string
+	// Claims are token claims, if authenticator provides them.
+	Claims map[string]interface{}
+}
+
+// HasScope check if principal is granted with scope.
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetPrincipal returns principal stored by Auth middleware.
+func GetPrincipal(ctx *jsonrpc.RequestCtx) *Principal {
+	if p, ok := ctx.Get(PrincipalKey); ok {
+		return p.(*Principal)
+	}
+
+	return nil
+}
+
+// Authenticator extracts and verifies request credentials. It returns nil principal and nil error if request
+// does not contain credentials of its kind.
+type Authenticator func(ctx *jsonrpc.RequestCtx) (*Principal, error)
+
+// TokenVerifier verifies bearer token or API key.
+type TokenVerifier func(ctx *jsonrpc.RequestCtx, token string) (*Principal, error)
+
+// BasicVerifier verifies HTTP Basic username and password.
+type BasicVerifier func(ctx *jsonrpc.RequestCtx, username, password string) (*Principal, error)
+
+// Auth authenticates client with the first authenticator which finds credentials in request, and stores
+// principal on RequestCtx. Then scopes required by service are checked. Unauthorized error is returned when
+// credentials are missing or invalid, forbidden error when principal lacks required scope.
+func Auth(authenticators ...Authenticator) jsonrpc.MiddlewareFunc {
+	return func(next jsonrpc.Handler) jsonrpc.Handler {
+		return func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+			var principal *Principal
+
+			for _, authenticate := range authenticators {
+				p, err := authenticate(ctx)
+				if err != nil {
+					return nil, jsonrpc.ErrUnauthorizedJSON()
+				}
+
+				if p != nil {
+					principal = p
+					break
+				}
+			}
+
+			if principal == nil {
+				return nil, jsonrpc.ErrUnauthorizedJSON()
+			}
+
+			ctx.Set(PrincipalKey, principal)
+
+			if err := authorize(ctx, principal); err != nil {
+				return nil, err
+			}
+
+			return next(ctx)
+		}
+	}
+}
+
+// authorize check that principal has all scopes required by service.
+func authorize(ctx *jsonrpc.RequestCtx, principal *Principal) jsonrpc.Error {
+	service := ctx.Service()
+	if service == nil {
+		return nil
+	}
+
+	for _, scope := range service.Scopes() {
+		if !principal.HasScope(scope) {
+			err := jsonrpc.ErrForbidden()
+			err.Data = map[string]interface{}{
+				"required_scopes": service.Scopes(),
+			}
+
+			return err.JSON()
+		}
+	}
+
+	return nil
+}
+
+// Bearer authenticates request with "Authorization: Bearer <token>" header.
+func Bearer(verify TokenVerifier) Authenticator {
+	return func(ctx *jsonrpc.RequestCtx) (*Principal, error) {
+		token, ok := authorization(ctx.R, "Bearer")
+		if !ok {
+			return nil, nil
+		}
+
+		return verified(verify(ctx, token))
+	}
+}
+
+// Basic authenticates request with HTTP Basic authorization header.
+func Basic(verify BasicVerifier) Authenticator {
+	return func(ctx *jsonrpc.RequestCtx) (*Principal, error) {
+		if _, ok := authorization(ctx.R, "Basic"); !ok {
+			return nil, nil
+		}
+
+		username, password, ok := ctx.R.BasicAuth()
+		if !ok {
+			return nil, ErrInvalidCredentials
+		}
+
+		return verified(verify(ctx, username, password))
+	}
+}
+
+// APIKey authenticates request with API key passed in header, e.g. X-API-Key.
+func APIKey(header string, verify TokenVerifier) Authenticator {
+	return func(ctx *jsonrpc.RequestCtx) (*Principal, error) {
+		key := ctx.R.Header.Get(header)
+		if key == "" {
+			return nil, nil
+		}
+
+		return verified(verify(ctx, key))
+	}
+}
+
+// authorization returns credentials of Authorization header with provided scheme.
+func authorization(r *http.Request, scheme string) (string, bool) {
+	auth := r.Header.Get("Authorization")
+	if len(auth) <= len(scheme) || !strings.EqualFold(auth[:len(scheme)], scheme) || auth[len(scheme)] != ' ' {
+		return "", false
+	}
+
+	return strings.TrimSpace(auth[len(scheme)+1:]), true
+}
+
+// verified treat nil principal returned by verifier as invalid credentials.
+func verified(p *Principal, err error) (*Principal, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	if p == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return p, nil
+}
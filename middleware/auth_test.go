@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+func TestAuth(t *testing.T) {
+	rpc := jsonrpc.NewServer(jsonrpc.Options{})
+	rpc.Use(Auth(
+		Bearer(func(ctx *jsonrpc.RequestCtx, token string) (*Principal, error) {
+			if token != "secret-token" {
+				return nil, ErrInvalidCredentials
+			}
+			return &Principal{Subject: "bearer", Scopes: []string{"admin"}}, nil
+		}),
+		Basic(func(ctx *jsonrpc.RequestCtx, username, password string) (*Principal, error) {
+			if username != "user" || password != "pass" {
+				return nil, ErrInvalidCredentials
+			}
+			return &Principal{Subject: username}, nil
+		}),
+		APIKey("X-API-Key", func(ctx *jsonrpc.RequestCtx, key string) (*Principal, error) {
+			if key != "key" {
+				return nil, nil
+			}
+			return &Principal{Subject: "service", Scopes: []string{"admin"}}, nil
+		}),
+	))
+
+	whoami := func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		return ctx.Result(GetPrincipal(ctx).Subject)
+	}
+	rpc.Register("whoami", whoami)
+	rpc.Register("admin", whoami).Require("admin")
+
+	var tc = []struct {
+		name, method string
+		header       http.Header
+		code         int
+		result       string
+	}{
+		{name: "NoCredentials", method: "whoami", code: jsonrpc.ErrorCodeUnauthorized},
+		{name: "InvalidBearer", method: "whoami", header: http.Header{"Authorization": {"Bearer wrong"}}, code: jsonrpc.ErrorCodeUnauthorized},
+		{name: "Bearer", method: "admin", header: http.Header{"Authorization": {"Bearer secret-token"}}, result: "bearer"},
+		{name: "Basic", method: "whoami", header: http.Header{"Authorization": {"Basic dXNlcjpwYXNz"}}, result: "user"},
+		{name: "BasicForbidden", method: "admin", header: http.Header{"Authorization": {"Basic dXNlcjpwYXNz"}}, code: jsonrpc.ErrorCodeForbidden},
+		{name: "InvalidAPIKey", method: "whoami", header: http.Header{"X-Api-Key": {"wrong"}}, code: jsonrpc.ErrorCodeUnauthorized},
+		{name: "APIKey", method: "admin", header: http.Header{"X-Api-Key": {"key"}}, result: "service"},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"jsonrpc":"2.0","method":"`+c.method+`","id":1}`))
+			for key, values := range c.header {
+				r.Header[key] = values
+			}
+			r.Header.Set("Content-Type", "application/json")
+
+			rpc.ServeHTTP(w, r)
+
+			var resp response
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Errorf("Received unexpected error:\n%+v", err)
+				t.FailNow()
+			}
+
+			if c.code != 0 {
+				if resp.Error == nil || resp.Error.Code != c.code {
+					t.Errorf("Unexpected result. Expected error %v. Got %v", c.code, w.Body.String())
+				}
+				return
+			}
+
+			if resp.Result != c.result {
+				t.Errorf("Unexpected result. Expected %v. Got %v", c.result, w.Body.String())
+			}
+		})
+	}
+}
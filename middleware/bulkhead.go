@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+type BulkheadOptions struct {
+	// MaxConcurrent is a max number of concurrently executed calls of every method.
+	MaxConcurrent int
+	// MaxWait is a max time call waits for execution slot. Zero rejects call immediately when all slots are busy.
+	MaxWait time.Duration
+}
+
+// Bulkhead limits number of concurrently executed calls per method, so slow method can not take all server
+// resources. Calls which do not get execution slot within MaxWait get too many concurrent calls error.
+func Bulkhead(opts BulkheadOptions) jsonrpc.MiddlewareFunc {
+	if opts.MaxConcurrent <= 0 {
+		panic("max concurrent calls must be positive")
+	}
+
+	var mu sync.Mutex
+	slots := make(map[string]chan struct{})
+
+	return func(next jsonrpc.Handler) jsonrpc.Handler {
+		return func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+			mu.Lock()
+			sem, ok := slots[ctx.Method]
+			if !ok {
+				sem = make(chan struct{}, opts.MaxConcurrent)
+				slots[ctx.Method] = sem
+			}
+			mu.Unlock()
+
+			if !acquire(ctx, sem, opts.MaxWait) {
+				return nil, jsonrpc.ErrBulkheadFullJSON()
+			}
+			defer func() { <-sem }()
+
+			return next(ctx)
+		}
+	}
+}
+
+// acquire takes slot from semaphore, waiting for at most wait duration or until request is canceled.
+func acquire(ctx *jsonrpc.RequestCtx, sem chan struct{}, wait time.Duration) bool {
+	select {
+	case sem <- struct{}{}:
+		return true
+	default:
+	}
+
+	if wait <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case sem <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Context().Done():
+		return false
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+func TestBulkhead(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+
+	handler := Bulkhead(BulkheadOptions{MaxConcurrent: 2, MaxWait: 200 * time.Millisecond})(func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		if ctx.Method == "slow" {
+			started <- struct{}{}
+			<-release
+		}
+		return ctx.Result("ok")
+	})
+
+	r, _ := http.NewRequest("POST", "/", nil)
+	callMethod := func(method string) jsonrpc.Error {
+		_, err := handler(&jsonrpc.RequestCtx{R: r, Method: method})
+		return err
+	}
+
+	done := make(chan jsonrpc.Error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { done <- callMethod("slow") }()
+	}
+	<-started
+	<-started
+
+	if err := callMethod("slow"); err.Code() != jsonrpc.ErrorCodeBulkheadFull {
+		t.Errorf("Unexpected error code. Expected %v. Got %v", jsonrpc.ErrorCodeBulkheadFull, err.Code())
+	}
+
+	if err := callMethod("fast"); err != nil {
+		t.Errorf("Received unexpected error:\n%s", err)
+	}
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		release <- struct{}{}
+	}()
+
+	// waits for released slot
+	go func() {
+		<-started
+		close(release)
+	}()
+	if err := callMethod("slow"); err != nil {
+		t.Errorf("Received unexpected error:\n%s", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Errorf("Received unexpected error:\n%s", err)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/lapitskyss/jsonrpc"
+	"github.com/lapitskyss/jsonrpc/jparser"
+)
+
+const defaultCacheMaxEntries = 1000
+
+type CacheOptions struct {
+	// TTL is a cache time to live for methods, which are not listed in Methods. Zero TTL disables caching.
+	TTL time.Duration
+	// Methods are per-method cache TTLs.
+	Methods map[string]time.Duration
+	// MaxEntries is a max number of cached results, least recently used results are evicted. Default is 1000.
+	MaxEntries int
+	// BypassHeader is a request header, which forces call when present. Default is "X-Cache-Bypass".
+	BypassHeader string
+}
+
+// Cache caches successful results of pure methods by method and params. Params are canonicalized, so params
+// which differ only in whitespace and keys order share cache entry. Concurrent identical calls are collapsed
+// into one handler call.
+func Cache(opts CacheOptions) jsonrpc.MiddlewareFunc {
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = defaultCacheMaxEntries
+	}
+
+	if opts.BypassHeader == "" {
+		opts.BypassHeader = "X-Cache-Bypass"
+	}
+
+	cache := newLRUCache(opts.MaxEntries)
+	var group flightGroup
+
+	return func(next jsonrpc.Handler) jsonrpc.Handler {
+		return func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+			ttl, ok := opts.Methods[ctx.Method]
+			if !ok {
+				ttl = opts.TTL
+			}
+
+			if ttl <= 0 {
+				return next(ctx)
+			}
+
+			params := ctx.Params
+			if len(params) > 0 {
+				canonical, err := jparser.Canonicalize(params)
+				if err != nil {
+					return next(ctx)
+				}
+				params = canonical
+			}
+
+			key := ctx.Method + "\x00" + string(params)
+
+			if ctx.R.Header.Get(opts.BypassHeader) != "" {
+				result, err := next(ctx)
+				if err == nil {
+					cache.set(key, result, ttl)
+				}
+
+				return result, err
+			}
+
+			if result, ok := cache.get(key); ok {
+				return result, nil
+			}
+
+			return group.do(key, func() (jsonrpc.Result, jsonrpc.Error) {
+				result, err := next(ctx)
+				if err == nil {
+					cache.set(key, result, ttl)
+				}
+
+				return result, err
+			})
+		}
+	}
+}
+
+// lruCache is a size bounded cache with expiration.
+type lruCache struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	ll      *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+type cacheEntry struct {
+	key     string
+	result  jsonrpc.Result
+	expires time.Time
+}
+
+func newLRUCache(maxEntries int) *lruCache {
+	return &lruCache{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+func (c *lruCache) get(key string) (jsonrpc.Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := el.Value.(*cacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.ll.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.ll.MoveToFront(el)
+	return entry.result, true
+}
+
+func (c *lruCache) set(key string, result jsonrpc.Result, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{key: key, result: result, expires: c.now().Add(ttl)}
+
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.ll.PushFront(entry)
+
+	if c.ll.Len() > c.maxEntries {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// flightGroup collapses concurrent calls with the same key into one.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+type flight struct {
+	wg     sync.WaitGroup
+	result jsonrpc.Result
+	err    jsonrpc.Error
+}
+
+func (g *flightGroup) do(key string, fn func() (jsonrpc.Result, jsonrpc.Error)) (jsonrpc.Result, jsonrpc.Error) {
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+
+	if f, ok := g.flights[key]; ok {
+		g.mu.Unlock()
+		f.wg.Wait()
+		return f.result, f.err
+	}
+
+	// waiters get internal error if fn panics
+	f := &flight{err: jsonrpc.ErrInternalJSON()}
+	f.wg.Add(1)
+	g.flights[key] = f
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.flights, key)
+		g.mu.Unlock()
+		f.wg.Done()
+	}()
+
+	f.result, f.err = fn()
+
+	return f.result, f.err
+}
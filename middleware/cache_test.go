@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+func TestCache(t *testing.T) {
+	var calls int32
+
+	rpc := jsonrpc.NewServer(jsonrpc.Options{})
+	rpc.Use(Cache(CacheOptions{
+		Methods: map[string]time.Duration{"get": time.Minute},
+	}))
+	handler := func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		return ctx.Result(atomic.AddInt32(&calls, 1))
+	}
+	rpc.Register("get", handler)
+	rpc.Register("set", handler)
+
+	send := func(method, params string, header http.Header) interface{} {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"jsonrpc":"2.0","method":"`+method+`","params":`+params+`,"id":1}`))
+		for key, values := range header {
+			r.Header[key] = values
+		}
+		r.Header.Set("Content-Type", "application/json")
+		rpc.ServeHTTP(w, r)
+
+		var resp response
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Result
+	}
+
+	if result := send("get", `{"a":1,"b":2}`, nil); result != 1.0 {
+		t.Errorf("Unexpected result. Expected %v. Got %v", 1, result)
+	}
+	if result := send("get", `{ "b": 2, "a": 1 }`, nil); result != 1.0 {
+		t.Errorf("Canonical params are not cached. Expected %v. Got %v", 1, result)
+	}
+	if result := send("get", `{"a":2}`, nil); result != 2.0 {
+		t.Errorf("Unexpected result. Expected %v. Got %v", 2, result)
+	}
+	if result := send("get", `{"a":1,"b":2}`, http.Header{"X-Cache-Bypass": {"1"}}); result != 3.0 {
+		t.Errorf("Cache was not bypassed. Expected %v. Got %v", 3, result)
+	}
+	if result := send("get", `{"a":1,"b":2}`, nil); result != 3.0 {
+		t.Errorf("Bypassed result was not cached. Expected %v. Got %v", 3, result)
+	}
+	if result := send("set", `{"a":1,"b":2}`, nil); result != 4.0 {
+		t.Errorf("Unexpected result. Expected %v. Got %v", 4, result)
+	}
+	if result := send("set", `{"a":1,"b":2}`, nil); result != 5.0 {
+		t.Errorf("Method without TTL was cached. Expected %v. Got %v", 5, result)
+	}
+}
+
+func TestCacheSingleflight(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+
+	handler := Cache(CacheOptions{TTL: time.Minute})(func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return ctx.Result("ok")
+	})
+
+	r, _ := http.NewRequest("POST", "/", nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := handler(&jsonrpc.RequestCtx{R: r, Method: "get", Params: []byte(`[1]`)})
+			if err != nil || string(result) != `"ok"` {
+				t.Errorf("Unexpected result %s %s", result, err)
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Unexpected handler calls. Expected %v. Got %v", 1, calls)
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	now := time.Now()
+	cache := newLRUCache(2)
+	cache.now = func() time.Time { return now }
+
+	cache.set("a", jsonrpc.Result("1"), time.Second)
+	cache.set("b", jsonrpc.Result("2"), time.Second)
+	cache.get("a")
+	cache.set("c", jsonrpc.Result("3"), time.Second)
+
+	if _, ok := cache.get("b"); ok {
+		t.Errorf("Least recently used entry was not evicted")
+	}
+	if _, ok := cache.get("a"); !ok {
+		t.Errorf("Recently used entry was evicted")
+	}
+
+	now = now.Add(time.Second)
+	if _, ok := cache.get("c"); ok {
+		t.Errorf("Expired entry was returned")
+	}
+}
@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+const (
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+)
+
+// CircuitState is a circuit breaker state.
+type CircuitState int
+
+const (
+	// CircuitClosed passes calls and counts consecutive failures.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects calls until open timeout is elapsed.
+	CircuitOpen
+	// CircuitHalfOpen passes limited number of trial calls, which close or open circuit again.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+
+	return "unknown"
+}
+
+type CircuitBreakerOptions struct {
+	// FailureThreshold is a number of consecutive failed calls which opens circuit. Default is 5.
+	FailureThreshold int
+	// OpenTimeout is a time circuit stays open before trial calls are passed. Default is 30 seconds.
+	OpenTimeout time.Duration
+	// HalfOpenCalls is a number of trial calls, which must succeed to close circuit. Default is 1.
+	HalfOpenCalls int
+	// FailureCodes are error codes counted as failures. Default is internal error.
+	FailureCodes []int
+	// Key returns circuit key for call, calls with the same key share circuit. Method name is used by default.
+	Key func(ctx *jsonrpc.RequestCtx) string
+	// OnStateChange is called when circuit state is changed.
+	OnStateChange func(key string, from, to CircuitState)
+
+	now func() time.Time
+}
+
+// CircuitBreaker fails fast when method calls keep failing, e.g. because of unavailable downstream. Every
+// method has its own circuit by default, calls with the same Key share circuit. When FailureThreshold
+// consecutive calls fail, circuit opens and calls get circuit open error with retry_after seconds in error
+// data. After OpenTimeout circuit is half-open and HalfOpenCalls trial calls are passed: if all of them succeed
+// circuit is closed, otherwise it opens again.
+//
+// Use it with Key returning downstream name to protect methods which depend on the same downstream.
+func CircuitBreaker(opts CircuitBreakerOptions) jsonrpc.MiddlewareFunc {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = defaultFailureThreshold
+	}
+
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = defaultOpenTimeout
+	}
+
+	if opts.HalfOpenCalls <= 0 {
+		opts.HalfOpenCalls = 1
+	}
+
+	if opts.FailureCodes == nil {
+		opts.FailureCodes = []int{jsonrpc.ErrorCodeInternal}
+	}
+
+	if opts.Key == nil {
+		opts.Key = KeyByMethod
+	}
+
+	if opts.now == nil {
+		opts.now = time.Now
+	}
+
+	b := &breaker{
+		opts:     opts,
+		circuits: make(map[string]*circuit),
+	}
+
+	return func(next jsonrpc.Handler) jsonrpc.Handler {
+		return func(ctx *jsonrpc.RequestCtx) (_ jsonrpc.Result, err jsonrpc.Error) {
+			key := opts.Key(ctx)
+
+			ok, trial, retryAfter := b.allow(key)
+			if !ok {
+				circuitErr := jsonrpc.ErrCircuitOpen()
+				if retryAfter > 0 {
+					circuitErr.Data = map[string]interface{}{
+						"retry_after": math.Ceil(retryAfter.Seconds()),
+					}
+				}
+
+				return nil, circuitErr.JSON()
+			}
+
+			// panic is counted as failure
+			failed := true
+			defer func() {
+				b.done(key, trial, failed)
+			}()
+
+			result, err := next(ctx)
+			failed = err != nil && b.isFailure(err.Code())
+
+			return result, err
+		}
+	}
+}
+
+type breaker struct {
+	opts CircuitBreakerOptions
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state CircuitState
+	// failures is a number of consecutive failures in closed state.
+	failures int
+	openedAt time.Time
+	// trials is a number of started trial calls in half-open state.
+	trials int
+	// successes is a number of succeeded trial calls in half-open state.
+	successes int
+}
+
+// allow check if call can be passed and if it is a trial call.
+func (b *breaker) allow(key string) (ok bool, trial bool, retryAfter time.Duration) {
+	b.mu.Lock()
+
+	c, exists := b.circuits[key]
+	if !exists {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+
+	from := c.state
+
+	switch c.state {
+	case CircuitClosed:
+		ok = true
+	case CircuitOpen:
+		elapsed := b.opts.now().Sub(c.openedAt)
+		if elapsed < b.opts.OpenTimeout {
+			retryAfter = b.opts.OpenTimeout - elapsed
+			break
+		}
+
+		c.state = CircuitHalfOpen
+		c.trials = 0
+		c.successes = 0
+		fallthrough
+	case CircuitHalfOpen:
+		if c.trials < b.opts.HalfOpenCalls {
+			c.trials++
+			ok, trial = true, true
+		}
+	}
+
+	to := c.state
+	b.mu.Unlock()
+
+	b.stateChanged(key, from, to)
+
+	return ok, trial, retryAfter
+}
+
+// done records call outcome.
+func (b *breaker) done(key string, trial, failed bool) {
+	b.mu.Lock()
+
+	c := b.circuits[key]
+	from := c.state
+
+	switch {
+	case trial && c.state == CircuitHalfOpen:
+		if failed {
+			c.state = CircuitOpen
+			c.openedAt = b.opts.now()
+			break
+		}
+
+		c.successes++
+		if c.successes >= b.opts.HalfOpenCalls {
+			c.state = CircuitClosed
+			c.failures = 0
+		}
+	case !trial && c.state == CircuitClosed:
+		if !failed {
+			c.failures = 0
+			break
+		}
+
+		c.failures++
+		if c.failures >= b.opts.FailureThreshold {
+			c.state = CircuitOpen
+			c.openedAt = b.opts.now()
+		}
+	}
+
+	to := c.state
+	b.mu.Unlock()
+
+	b.stateChanged(key, from, to)
+}
+
+func (b *breaker) stateChanged(key string, from, to CircuitState) {
+	if from != to && b.opts.OnStateChange != nil {
+		b.opts.OnStateChange(key, from, to)
+	}
+}
+
+func (b *breaker) isFailure(code int) bool {
+	for _, c := range b.opts.FailureCodes {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
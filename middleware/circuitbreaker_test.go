@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	fail := true
+
+	var changes []string
+	handler := CircuitBreaker(CircuitBreakerOptions{
+		FailureThreshold: 2,
+		OpenTimeout:      10 * time.Second,
+		OnStateChange: func(method string, from, to CircuitState) {
+			changes = append(changes, method+":"+from.String()+"->"+to.String())
+		},
+		now: func() time.Time { return now },
+	})(func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		if fail {
+			return nil, jsonrpc.ErrInternalJSON()
+		}
+		return ctx.Result("ok")
+	})
+
+	r, _ := http.NewRequest("POST", "/", nil)
+	callMethod := func(method string) int {
+		_, err := handler(&jsonrpc.RequestCtx{R: r, Method: method})
+		if err == nil {
+			return 0
+		}
+		return err.Code()
+	}
+
+	steps := []struct {
+		name   string
+		fail   bool
+		wait   time.Duration
+		method string
+		code   int
+	}{
+		{"Failure", true, 0, "a", jsonrpc.ErrorCodeInternal},
+		{"OtherMethod", true, 0, "b", jsonrpc.ErrorCodeInternal},
+		{"Threshold", true, 0, "a", jsonrpc.ErrorCodeInternal},
+		{"Open", false, 0, "a", jsonrpc.ErrorCodeCircuitOpen},
+		{"OtherMethodClosed", false, 0, "b", 0},
+		{"HalfOpenFailure", true, 10 * time.Second, "a", jsonrpc.ErrorCodeInternal},
+		{"OpenAgain", false, 0, "a", jsonrpc.ErrorCodeCircuitOpen},
+		{"HalfOpenSuccess", false, 10 * time.Second, "a", 0},
+		{"Closed", false, 0, "a", 0},
+	}
+
+	for _, step := range steps {
+		fail = step.fail
+		now = now.Add(step.wait)
+
+		if code := callMethod(step.method); code != step.code {
+			t.Errorf("%s: unexpected error code. Expected %v. Got %v", step.name, step.code, code)
+		}
+	}
+
+	expected := []string{
+		"a:closed->open",
+		"a:open->half-open",
+		"a:half-open->open",
+		"a:open->half-open",
+		"a:half-open->closed",
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Unexpected state changes. Expected %v. Got %v", expected, changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("Unexpected state change. Expected %v. Got %v", expected[i], changes[i])
+		}
+	}
+}
+
+func TestCircuitBreakerRetryAfter(t *testing.T) {
+	rpc := jsonrpc.NewServer(jsonrpc.Options{})
+	rpc.Register("fail", func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		return nil, jsonrpc.ErrInternalJSON()
+	}).Use(CircuitBreaker(CircuitBreakerOptions{FailureThreshold: 1, OpenTimeout: 5 * time.Second}))
+
+	call(t, rpc, `{"jsonrpc":"2.0","method":"fail","id":1}`)
+	resp := call(t, rpc, `{"jsonrpc":"2.0","method":"fail","id":2}`)[0]
+
+	if resp.Error == nil || resp.Error.Code != jsonrpc.ErrorCodeCircuitOpen {
+		t.Fatalf("Unexpected error. Expected %v. Got %+v", jsonrpc.ErrorCodeCircuitOpen, resp.Error)
+	}
+	if retryAfter := resp.Error.Data.(map[string]interface{})["retry_after"]; retryAfter != 5.0 {
+		t.Errorf("Unexpected retry_after. Expected %v. Got %v", 5, retryAfter)
+	}
+}
+
+func TestCircuitBreakerKey(t *testing.T) {
+	rpc := jsonrpc.NewServer(jsonrpc.Options{})
+	rpc.Use(CircuitBreaker(CircuitBreakerOptions{
+		FailureThreshold: 1,
+		Key: func(ctx *jsonrpc.RequestCtx) string {
+			return "payments"
+		},
+	}))
+	rpc.Register("charge", func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		return nil, jsonrpc.ErrInternalJSON()
+	})
+	rpc.Register("refund", func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		return ctx.Result("ok")
+	})
+
+	call(t, rpc, `{"jsonrpc":"2.0","method":"charge","id":1}`)
+	resp := call(t, rpc, `{"jsonrpc":"2.0","method":"refund","id":2}`)[0]
+
+	if resp.Error == nil || resp.Error.Code != jsonrpc.ErrorCodeCircuitOpen {
+		t.Errorf("Unexpected error. Expected %v. Got %+v", jsonrpc.ErrorCodeCircuitOpen, resp.Error)
+	}
+}
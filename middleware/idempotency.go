@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lapitskyss/jsonrpc"
+	"github.com/lapitskyss/jsonrpc/jparser"
+)
+
+const (
+	// IdempotencyKeyHeader is a default request header with client generated idempotency key.
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	defaultIdempotencyTTL = 24 * time.Hour
+)
+
+// IdempotencyRecord is a recorded call response.
+type IdempotencyRecord struct {
+	// ParamsHash is a sha256 hash of canonical call params.
+	ParamsHash [sha256.Size]byte
+	Result     jsonrpc.Result
+	Error      jsonrpc.Error
+}
+
+// IdempotencyStore keeps recorded responses by idempotency key.
+type IdempotencyStore interface {
+	// Get returns record stored by key, false if there is no record or it is expired.
+	Get(key string) (IdempotencyRecord, bool)
+	// Set stores record by key for ttl.
+	Set(key string, record IdempotencyRecord, ttl time.Duration)
+}
+
+type IdempotencyOptions struct {
+	// Store keeps recorded responses, NewMemoryIdempotencyStore is used by default.
+	Store IdempotencyStore
+	// TTL is a replay window, default is 24 hours.
+	TTL time.Duration
+	// Header is a request header with idempotency key, default is "Idempotency-Key".
+	Header string
+	// Identity returns client identity, which is combined with idempotency key header, or with request id when
+	// header is missing. Principal subject stored by Auth middleware is used by default, client IP if there is none.
+	Identity func(ctx *jsonrpc.RequestCtx) string
+}
+
+// Idempotency records responses of mutating methods and replays them when call is retried. Call is identified
+// by client identity and idempotency key header, or by client identity and request id if there is no header.
+// Method and batch index are part of the key, so one header may be used for a whole batch. Call retried with the
+// same key and different params gets idempotency conflict error. Concurrent calls with the same key are executed once.
+//
+// Internal and server errors (-32000 to -32099) are not recorded, so such calls can be retried. Notifications
+// without idempotency key header are passed through.
+//
+// Use it as Service middleware to mark service methods as idempotent.
+func Idempotency(opts IdempotencyOptions) jsonrpc.MiddlewareFunc {
+	if opts.Store == nil {
+		opts.Store = NewMemoryIdempotencyStore()
+	}
+
+	if opts.TTL <= 0 {
+		opts.TTL = defaultIdempotencyTTL
+	}
+
+	if opts.Header == "" {
+		opts.Header = IdempotencyKeyHeader
+	}
+
+	if opts.Identity == nil {
+		opts.Identity = identity
+	}
+
+	var locks keyLocks
+
+	return func(next jsonrpc.Handler) jsonrpc.Handler {
+		return func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+			key := idempotencyKey(ctx, opts)
+			if key == "" {
+				return next(ctx)
+			}
+
+			hash := paramsHash(ctx.Params)
+
+			unlock := locks.lock(key)
+			defer unlock()
+
+			if record, ok := opts.Store.Get(key); ok {
+				if record.ParamsHash != hash {
+					return nil, jsonrpc.ErrIdempotencyConflictJSON()
+				}
+
+				return record.Result, record.Error
+			}
+
+			result, err := next(ctx)
+
+			if err == nil || !isRetryable(err.Code()) {
+				opts.Store.Set(key, IdempotencyRecord{ParamsHash: hash, Result: result, Error: err}, opts.TTL)
+			}
+
+			return result, err
+		}
+	}
+}
+
+func idempotencyKey(ctx *jsonrpc.RequestCtx, opts IdempotencyOptions) string {
+	var key string
+
+	if header := ctx.R.Header.Get(opts.Header); header != "" {
+		// identity is a part of key, so client can't replay response recorded for other client
+		key = "key\x00" + opts.Identity(ctx) + "\x00" + header
+		if ctx.BatchSize > 0 {
+			key += "\x00" + strconv.Itoa(ctx.BatchIndex)
+		}
+	} else if id := ctx.GetID(); id.Kind() == jsonrpc.IDString || id.Kind() == jsonrpc.IDNumber {
+		// id kind is a part of key, string id "1" and number id 1 are different calls
+		key = "id\x00" + opts.Identity(ctx) + "\x00" + id.Kind().String() + "\x00" + id.String()
+	} else {
+		return ""
+	}
+
+	return ctx.Method + "\x00" + key
+}
+
+// identity returns principal subject or client IP.
+func identity(ctx *jsonrpc.RequestCtx) string {
+	if p := GetPrincipal(ctx); p != nil {
+		return "sub:" + p.Subject
+	}
+
+	return "ip:" + KeyByIP(ctx)
+}
+
+func paramsHash(params []byte) [sha256.Size]byte {
+	if len(params) > 0 {
+		if canonical, err := jparser.Canonicalize(params); err == nil {
+			params = canonical
+		}
+	}
+
+	return sha256.Sum256(params)
+}
+
+// isRetryable check if error is internal or server error, which may be gone on retry.
+func isRetryable(code int) bool {
+	return code == jsonrpc.ErrorCodeInternal || code <= -32000 && code >= -32099
+}
+
+// keyLocks is a set of mutexes by key.
+type keyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lock locks key and returns unlock function.
+func (l *keyLocks) lock(key string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*keyLock)
+	}
+	kl, ok := l.locks[key]
+	if !ok {
+		kl = &keyLock{}
+		l.locks[key] = kl
+	}
+	kl.refs++
+	l.mu.Unlock()
+
+	kl.mu.Lock()
+
+	return func() {
+		kl.mu.Unlock()
+
+		l.mu.Lock()
+		kl.refs--
+		if kl.refs == 0 {
+			delete(l.locks, key)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// MemoryIdempotencyStore is in-memory idempotency store. Expired records are removed periodically.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]memoryRecord
+	sets    int
+	now     func() time.Time
+}
+
+type memoryRecord struct {
+	record  IdempotencyRecord
+	expires time.Time
+}
+
+// NewMemoryIdempotencyStore creates in-memory idempotency store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		records: make(map[string]memoryRecord),
+		now:     time.Now,
+	}
+}
+
+// Get implements IdempotencyStore interface.
+func (s *MemoryIdempotencyStore) Get(key string) (IdempotencyRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.records[key]
+	if !ok || !s.now().Before(r.expires) {
+		return IdempotencyRecord{}, false
+	}
+
+	return r.record, true
+}
+
+// Set implements IdempotencyStore interface.
+func (s *MemoryIdempotencyStore) Set(key string, record IdempotencyRecord, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	s.sets++
+	if s.sets%sweepInterval == 0 {
+		for k, r := range s.records {
+			if !now.Before(r.expires) {
+				delete(s.records, k)
+			}
+		}
+	}
+
+	s.records[key] = memoryRecord{record: record, expires: now.Add(ttl)}
+}
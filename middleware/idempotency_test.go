@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+func TestIdempotency(t *testing.T) {
+	var calls int32
+
+	store := NewMemoryIdempotencyStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	rpc := jsonrpc.NewServer(jsonrpc.Options{})
+	payments := rpc.Register("charge", func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		return ctx.Result(atomic.AddInt32(&calls, 1))
+	})
+	payments.Use(Idempotency(IdempotencyOptions{Store: store, TTL: time.Minute}))
+
+	withKey := func(key string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key != "" {
+				r.Header.Set(IdempotencyKeyHeader, key)
+			}
+			rpc.ServeHTTP(w, r)
+		})
+	}
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		in      string
+		result  interface{}
+		code    int
+	}{
+		{"FirstCall", withKey("k1"), `{"jsonrpc":"2.0","method":"charge","params":{"a":1,"b":2},"id":1}`, 1.0, 0},
+		{"Replay", withKey("k1"), `{"jsonrpc":"2.0","method":"charge","params":{"b":2, "a":1},"id":2}`, 1.0, 0},
+		{"Conflict", withKey("k1"), `{"jsonrpc":"2.0","method":"charge","params":{"a":2},"id":3}`, nil, jsonrpc.ErrorCodeIdempotencyConflict},
+		{"OtherKey", withKey("k2"), `{"jsonrpc":"2.0","method":"charge","params":{"a":1,"b":2},"id":4}`, 2.0, 0},
+		{"RequestID", withKey(""), `{"jsonrpc":"2.0","method":"charge","params":[1],"id":"abc"}`, 3.0, 0},
+		{"RequestIDReplay", withKey(""), `{"jsonrpc":"2.0","method":"charge","params":[1],"id":"abc"}`, 3.0, 0},
+		{"OtherRequestID", withKey(""), `{"jsonrpc":"2.0","method":"charge","params":[1],"id":"abd"}`, 4.0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := call(t, test.handler, test.in)[0]
+
+			if test.code != 0 {
+				if resp.Error == nil || resp.Error.Code != test.code {
+					t.Errorf("Unexpected error. Expected %v. Got %+v", test.code, resp.Error)
+				}
+				return
+			}
+
+			if resp.Error != nil {
+				t.Errorf("Received unexpected error:\n%+v", resp.Error)
+			}
+			if resp.Result != test.result {
+				t.Errorf("Unexpected result. Expected %v. Got %v", test.result, resp.Result)
+			}
+		})
+	}
+
+	now = now.Add(time.Minute)
+
+	resp := call(t, withKey("k1"), `{"jsonrpc":"2.0","method":"charge","params":{"a":2},"id":5}`)[0]
+	if resp.Result != 5.0 {
+		t.Errorf("Expired key was replayed. Expected %v. Got %v", 5, resp.Result)
+	}
+}
+
+func TestIdempotencyRetryableErrors(t *testing.T) {
+	var calls int32
+
+	handler := Idempotency(IdempotencyOptions{})(func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return nil, jsonrpc.ErrInternalJSON()
+		}
+		return nil, jsonrpc.ErrInvalidParamsJSON()
+	})
+
+	r, _ := http.NewRequest("POST", "/", nil)
+	r.Header.Set(IdempotencyKeyHeader, "k")
+
+	for i, code := range []int{jsonrpc.ErrorCodeInternal, jsonrpc.ErrorCodeInvalidParams, jsonrpc.ErrorCodeInvalidParams} {
+		_, err := handler(&jsonrpc.RequestCtx{R: r, Method: "charge"})
+		if err.Code() != code {
+			t.Errorf("Unexpected error code in call %d. Expected %v. Got %v", i, code, err.Code())
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("Unexpected handler calls. Expected %v. Got %v", 2, calls)
+	}
+}
+
+func TestIdempotencyConcurrent(t *testing.T) {
+	var calls int32
+
+	handler := Idempotency(IdempotencyOptions{})(func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		time.Sleep(10 * time.Millisecond)
+		return ctx.Result(atomic.AddInt32(&calls, 1))
+	})
+
+	r, _ := http.NewRequest("POST", "/", nil)
+	r.Header.Set(IdempotencyKeyHeader, "k")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, _ := handler(&jsonrpc.RequestCtx{R: r, Method: "charge", Params: []byte(`[1]`)})
+			if string(result) != "1" {
+				t.Errorf("Unexpected result. Expected %v. Got %s", 1, result)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Unexpected handler calls. Expected %v. Got %v", 1, calls)
+	}
+}
+
+func TestIdempotencyIdentity(t *testing.T) {
+	var calls int32
+
+	handler := Idempotency(IdempotencyOptions{})(func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		return ctx.Result(atomic.AddInt32(&calls, 1))
+	})
+
+	tests := []struct {
+		remoteAddr string
+		result     string
+	}{
+		{"10.0.0.1:1234", "1"},
+		{"10.0.0.2:1234", "2"},
+		{"10.0.0.1:5678", "1"},
+	}
+
+	for _, test := range tests {
+		r, _ := http.NewRequest("POST", "/", nil)
+		r.RemoteAddr = test.remoteAddr
+		r.Header.Set(IdempotencyKeyHeader, "k")
+
+		result, err := handler(&jsonrpc.RequestCtx{R: r, Method: "charge", Params: []byte(`[1]`)})
+		if err != nil || string(result) != test.result {
+			t.Errorf("Unexpected result for %v. Expected %v. Got %s %s", test.remoteAddr, test.result, result, err)
+		}
+	}
+}
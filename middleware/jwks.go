@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+)
+
+var ErrKeyNotFound = errors.New("jwt: key not found")
+
+// minHMACSecretLen is a minimal length of HS256 secret, secret must be at least as long as SHA-256 hash.
+const minHMACSecretLen = 32
+
+// KeySet is a local set of keys used to verify JWT signatures. Keys are selected by token "kid" header,
+// token without "kid" can be verified only when set contains a single key.
+type KeySet struct {
+	mu   sync.RWMutex
+	keys map[string]interface{}
+}
+
+// NewKeySet creates empty key set.
+func NewKeySet() *KeySet {
+	return &KeySet{
+		keys: make(map[string]interface{}),
+	}
+}
+
+// AddHMAC adds HS256 secret. Secret should be random and at least 32 bytes long, empty secret never verifies
+// signature.
+func (ks *KeySet) AddHMAC(kid string, secret []byte) {
+	ks.add(kid, secret)
+}
+
+// AddRSA adds RS256 public key.
+func (ks *KeySet) AddRSA(kid string, key *rsa.PublicKey) {
+	ks.add(kid, key)
+}
+
+// AddECDSA adds ES256 public key.
+func (ks *KeySet) AddECDSA(kid string, key *ecdsa.PublicKey) {
+	ks.add(kid, key)
+}
+
+func (ks *KeySet) add(kid string, key interface{}) {
+	ks.mu.Lock()
+	ks.keys[kid] = key
+	ks.mu.Unlock()
+}
+
+// key returns key by id.
+func (ks *KeySet) key(kid string) (interface{}, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if key, ok := ks.keys[kid]; ok {
+		return key, nil
+	}
+
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, nil
+		}
+	}
+
+	return nil, ErrKeyNotFound
+}
+
+// jwk is a JSON Web Key, see RFC 7517.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// oct
+	K string `json:"k"`
+}
+
+// ParseJWKS parses JSON Web Key Set. RSA, P-256 EC and symmetric keys are supported, keys for other
+// purposes than signature are skipped.
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+
+	ks := NewKeySet()
+
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		switch k.Kty {
+		case "RSA":
+			n, err := decodeBigInt(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("jwks: key %q: %w", k.Kid, err)
+			}
+			e, err := decodeBigInt(k.E)
+			if err != nil {
+				return nil, fmt.Errorf("jwks: key %q: %w", k.Kid, err)
+			}
+			if !e.IsInt64() || e.Int64() > 1<<31-1 {
+				return nil, fmt.Errorf("jwks: key %q: invalid exponent", k.Kid)
+			}
+
+			ks.AddRSA(k.Kid, &rsa.PublicKey{N: n, E: int(e.Int64())})
+		case "EC":
+			if k.Crv != "P-256" {
+				return nil, fmt.Errorf("jwks: key %q: unsupported curve %q", k.Kid, k.Crv)
+			}
+			x, err := decodeBigInt(k.X)
+			if err != nil {
+				return nil, fmt.Errorf("jwks: key %q: %w", k.Kid, err)
+			}
+			y, err := decodeBigInt(k.Y)
+			if err != nil {
+				return nil, fmt.Errorf("jwks: key %q: %w", k.Kid, err)
+			}
+
+			curve := elliptic.P256()
+			if !curve.IsOnCurve(x, y) {
+				return nil, fmt.Errorf("jwks: key %q: point is not on curve", k.Kid)
+			}
+
+			ks.AddECDSA(k.Kid, &ecdsa.PublicKey{Curve: curve, X: x, Y: y})
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil {
+				return nil, fmt.Errorf("jwks: key %q: %w", k.Kid, err)
+			}
+
+			// short secret can be brute forced, empty one makes tokens signed with empty key valid
+			if len(secret) < minHMACSecretLen {
+				return nil, fmt.Errorf("jwks: key %q: secret is shorter than %d bytes", k.Kid, minHMACSecretLen)
+			}
+
+			ks.AddHMAC(k.Kid, secret)
+		default:
+			return nil, fmt.Errorf("jwks: key %q: unsupported key type %q", k.Kid, k.Kty)
+		}
+	}
+
+	return ks, nil
+}
+
+// LoadJWKS reads JSON Web Key Set from file.
+func LoadJWKS(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseJWKS(data)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,308 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+var (
+	ErrMalformedToken     = errors.New("jwt: malformed token")
+	ErrUnsupportedAlg     = errors.New("jwt: unsupported algorithm")
+	ErrInvalidSignature   = errors.New("jwt: invalid signature")
+	ErrTokenExpired       = errors.New("jwt: token is expired")
+	ErrTokenNotValidYet   = errors.New("jwt: token is not valid yet")
+	ErrInvalidIssuer      = errors.New("jwt: invalid issuer")
+	ErrInvalidAudience    = errors.New("jwt: invalid audience")
+	ErrInvalidClaimFormat = errors.New("jwt: invalid claim format")
+)
+
+type JWTOptions struct {
+	// Keys is a key set to verify token signatures.
+	Keys *KeySet
+	// Issuer is expected "iss" claim, not checked if empty.
+	Issuer string
+	// Audience is expected "aud" claim value, not checked if empty.
+	Audience string
+	// Leeway is allowed clock skew for "exp" and "nbf" claims.
+	Leeway time.Duration
+	// ScopeClaim is a claim with principal scopes, space separated string or array. Default is "scope".
+	ScopeClaim string
+	// MethodsClaim is a claim with allowed method patterns, e.g. ["billing.*"]. Patterns use path.Match
+	// syntax. Methods are not restricted if empty.
+	MethodsClaim string
+
+	now func() time.Time
+}
+
+// GetClaims returns verified JWT claims stored by JWT middleware.
+func GetClaims(ctx *jsonrpc.RequestCtx) map[string]interface{} {
+	if p := GetPrincipal(ctx); p != nil {
+		return p.Claims
+	}
+
+	return nil
+}
+
+// JWT authenticates client with JWT bearer token signed with HS256, RS256 or ES256. Claims are available
+// with GetClaims, "sub" claim becomes principal subject and scope claim principal scopes. If MethodsClaim is
+// set, called method must match one of claim patterns, otherwise forbidden error is returned.
+func JWT(opts JWTOptions) jsonrpc.MiddlewareFunc {
+	auth := Auth(JWTAuthenticator(opts))
+
+	return func(next jsonrpc.Handler) jsonrpc.Handler {
+		return auth(func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+			if opts.MethodsClaim != "" && !methodAllowed(GetClaims(ctx)[opts.MethodsClaim], ctx.Method) {
+				return nil, jsonrpc.ErrForbiddenJSON()
+			}
+
+			return next(ctx)
+		})
+	}
+}
+
+// JWTAuthenticator returns bearer authenticator, which verifies JWT. It can be combined with other
+// authenticators in Auth middleware.
+func JWTAuthenticator(opts JWTOptions) Authenticator {
+	if opts.Keys == nil {
+		panic("jwt key set is required")
+	}
+
+	if opts.ScopeClaim == "" {
+		opts.ScopeClaim = "scope"
+	}
+
+	return Bearer(func(ctx *jsonrpc.RequestCtx, token string) (*Principal, error) {
+		claims, err := VerifyJWT(token, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		scopes, err := stringList(claims[opts.ScopeClaim])
+		if err != nil {
+			return nil, err
+		}
+
+		subject, _ := claims["sub"].(string)
+
+		return &Principal{
+			Subject: subject,
+			Scopes:  scopes,
+			Claims:  claims,
+		}, nil
+	})
+}
+
+// VerifyJWT verifies token signature and registered claims, and returns token claims.
+func VerifyJWT(token string, opts JWTOptions) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	key, err := opts.Keys.key(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	if err = validateClaims(claims, opts); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrMalformedToken
+	}
+
+	if err = json.Unmarshal(data, v); err != nil {
+		return ErrMalformedToken
+	}
+
+	return nil
+}
+
+// verifySignature verify signature with key. Key type must match algorithm, so that public key can not be
+// used as HMAC secret.
+func verifySignature(alg string, key interface{}, signingInput string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch alg {
+	case "HS256":
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return ErrUnsupportedAlg
+		}
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrInvalidSignature
+		}
+	case "RS256":
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrUnsupportedAlg
+		}
+
+		if rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature) != nil {
+			return ErrInvalidSignature
+		}
+	case "ES256":
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrUnsupportedAlg
+		}
+
+		if len(signature) != 64 {
+			return ErrInvalidSignature
+		}
+
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(publicKey, digest[:], r, s) {
+			return ErrInvalidSignature
+		}
+	default:
+		return ErrUnsupportedAlg
+	}
+
+	return nil
+}
+
+func validateClaims(claims map[string]interface{}, opts JWTOptions) error {
+	now := time.Now()
+	if opts.now != nil {
+		now = opts.now()
+	}
+
+	if exp, ok, err := timeClaim(claims, "exp"); err != nil {
+		return err
+	} else if ok && !now.Before(exp.Add(opts.Leeway)) {
+		return ErrTokenExpired
+	}
+
+	if nbf, ok, err := timeClaim(claims, "nbf"); err != nil {
+		return err
+	} else if ok && now.Add(opts.Leeway).Before(nbf) {
+		return ErrTokenNotValidYet
+	}
+
+	if opts.Issuer != "" && claims["iss"] != opts.Issuer {
+		return ErrInvalidIssuer
+	}
+
+	if opts.Audience != "" {
+		aud, err := stringList(claims["aud"])
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for _, a := range aud {
+			if a == opts.Audience {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return ErrInvalidAudience
+		}
+	}
+
+	return nil
+}
+
+// timeClaim returns NumericDate claim value.
+func timeClaim(claims map[string]interface{}, name string) (time.Time, bool, error) {
+	v, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	seconds, ok := v.(float64)
+	if !ok || math.IsNaN(seconds) || seconds >= math.MaxInt64 || seconds <= math.MinInt64 {
+		return time.Time{}, false, ErrInvalidClaimFormat
+	}
+
+	// nanoseconds since epoch overflow int64 after year 2262, so seconds and fraction are converted separately
+	sec, frac := math.Modf(seconds)
+
+	return time.Unix(int64(sec), int64(frac*float64(time.Second))), true, nil
+}
+
+// stringList returns claim, which is a space separated string or an array of strings, as list.
+func stringList(v interface{}) ([]string, error) {
+	switch value := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return strings.Fields(value), nil
+	case []interface{}:
+		list := make([]string, 0, len(value))
+		for _, item := range value {
+			s, ok := item.(string)
+			if !ok {
+				return nil, ErrInvalidClaimFormat
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	}
+
+	return nil, ErrInvalidClaimFormat
+}
+
+// methodAllowed check if method matches one of patterns.
+func methodAllowed(patterns interface{}, method string) bool {
+	list, err := stringList(patterns)
+	if err != nil {
+		return false
+	}
+
+	for _, pattern := range list {
+		if ok, _ := path.Match(pattern, method); ok {
+			return true
+		}
+	}
+
+	return false
+}
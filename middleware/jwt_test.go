@@ -0,0 +1,243 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+func TestVerifyJWT(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	jwks, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+			{"kty": "oct", "kid": "hmac", "k": b64(secret)},
+			{"kty": "RSA", "kid": "enc", "use": "enc"},
+		},
+	})
+
+	keys, err := ParseJWKS(jwks)
+	if err != nil {
+		t.Errorf("Received unexpected error:\n%+v", err)
+		t.FailNow()
+	}
+
+	now := time.Unix(1700000000, 0)
+	opts := JWTOptions{
+		Keys:     keys,
+		Issuer:   "issuer",
+		Audience: "api",
+		Leeway:   time.Minute,
+		now:      func() time.Time { return now },
+	}
+
+	valid := map[string]interface{}{"sub": "user", "iss": "issuer", "aud": []string{"api"}, "exp": now.Unix() + 60, "nbf": now.Unix()}
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := make(map[string]interface{})
+		for k, v := range valid {
+			c[k] = v
+		}
+		for k, v := range overrides {
+			c[k] = v
+		}
+		return c
+	}
+
+	var tc = []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{name: "HS256", token: signHS256(t, "hmac", secret, valid)},
+		{name: "RS256", token: signRS256(t, "rsa", rsaKey, valid)},
+		{name: "ES256", token: signES256(t, "ec", ecKey, valid)},
+		{name: "WrongSecret", token: signHS256(t, "hmac", []byte("wrong"), valid), err: ErrInvalidSignature},
+		{name: "AlgConfusion", token: signHS256(t, "rsa", secret, valid), err: ErrUnsupportedAlg},
+		{name: "UnknownKey", token: signHS256(t, "unknown", secret, valid), err: ErrKeyNotFound},
+		{name: "None", token: encodeToken(t, map[string]string{"alg": "none", "kid": "hmac"}, valid) + ".", err: ErrUnsupportedAlg},
+		{name: "Expired", token: signHS256(t, "hmac", secret, claims(map[string]interface{}{"exp": now.Unix() - 61})), err: ErrTokenExpired},
+		{name: "ExpiredLeeway", token: signHS256(t, "hmac", secret, claims(map[string]interface{}{"exp": now.Unix() - 30}))},
+		{name: "FarFuture", token: signHS256(t, "hmac", secret, claims(map[string]interface{}{"exp": 1e10}))},
+		{name: "FractionalExpired", token: signHS256(t, "hmac", secret, claims(map[string]interface{}{"exp": float64(now.Unix()) - 60.5})), err: ErrTokenExpired},
+		{name: "OutOfRange", token: signHS256(t, "hmac", secret, claims(map[string]interface{}{"exp": 1e300})), err: ErrInvalidClaimFormat},
+		{name: "NotValidYet", token: signHS256(t, "hmac", secret, claims(map[string]interface{}{"nbf": now.Unix() + 61})), err: ErrTokenNotValidYet},
+		{name: "Issuer", token: signHS256(t, "hmac", secret, claims(map[string]interface{}{"iss": "other"})), err: ErrInvalidIssuer},
+		{name: "Audience", token: signHS256(t, "hmac", secret, claims(map[string]interface{}{"aud": "other"})), err: ErrInvalidAudience},
+		{name: "Malformed", token: "abc", err: ErrMalformedToken},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			claims, err := VerifyJWT(c.token, opts)
+			if err != c.err {
+				t.Errorf("Unexpected error. Expected %v. Got %v", c.err, err)
+				t.FailNow()
+			}
+
+			if err == nil && claims["sub"] != "user" {
+				t.Errorf("Unexpected claims %v", claims)
+			}
+		})
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	var tc = []struct {
+		name string
+		key  map[string]string
+		ok   bool
+	}{
+		{name: "HMAC", key: map[string]string{"kty": "oct", "kid": "hmac", "k": b64(bytes.Repeat([]byte("s"), 32))}, ok: true},
+		{name: "EmptySecret", key: map[string]string{"kty": "oct", "kid": "hmac", "k": ""}},
+		{name: "MissingSecret", key: map[string]string{"kty": "oct", "kid": "hmac"}},
+		{name: "ShortSecret", key: map[string]string{"kty": "oct", "kid": "hmac", "k": b64([]byte("secret"))}},
+		{name: "EmptyModulus", key: map[string]string{"kty": "RSA", "kid": "rsa", "n": "", "e": "AQAB"}},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			jwks, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{c.key}})
+
+			_, err := ParseJWKS(jwks)
+			if ok := err == nil; ok != c.ok {
+				t.Errorf("Unexpected result. Expected %v. Got %v", c.ok, err)
+			}
+		})
+	}
+}
+
+func TestVerifyJWTEmptySecret(t *testing.T) {
+	keys := NewKeySet()
+	keys.AddHMAC("hmac", nil)
+
+	token := signHS256(t, "hmac", nil, map[string]interface{}{"sub": "user"})
+	if _, err := VerifyJWT(token, JWTOptions{Keys: keys}); err != ErrUnsupportedAlg {
+		t.Errorf("Unexpected error. Expected %v. Got %v", ErrUnsupportedAlg, err)
+	}
+}
+
+func TestJWT(t *testing.T) {
+	secret := []byte("secret")
+	keys := NewKeySet()
+	keys.AddHMAC("", secret)
+
+	rpc := jsonrpc.NewServer(jsonrpc.Options{})
+	rpc.Use(JWT(JWTOptions{Keys: keys, MethodsClaim: "methods"}))
+
+	subject := func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		return ctx.Result(GetClaims(ctx)["sub"])
+	}
+	rpc.Register("billing.charge", subject).Require("billing")
+	rpc.Register("users.delete", subject)
+
+	token := signHS256(t, "", secret, map[string]interface{}{
+		"sub":     "user",
+		"scope":   "billing read",
+		"methods": []string{"billing.*"},
+	})
+
+	var tc = []struct {
+		name, method, token string
+		code                int
+	}{
+		{name: "Allowed", method: "billing.charge", token: token},
+		{name: "MethodNotAllowed", method: "users.delete", token: token, code: jsonrpc.ErrorCodeForbidden},
+		{name: "NoToken", method: "billing.charge", code: jsonrpc.ErrorCodeUnauthorized},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"jsonrpc":"2.0","method":"`+c.method+`","id":1}`))
+			r.Header.Set("Content-Type", "application/json")
+			if c.token != "" {
+				r.Header.Set("Authorization", "Bearer "+c.token)
+			}
+
+			rpc.ServeHTTP(w, r)
+
+			var resp response
+			_ = json.Unmarshal(w.Body.Bytes(), &resp)
+
+			if c.code != 0 {
+				if resp.Error == nil || resp.Error.Code != c.code {
+					t.Errorf("Unexpected result. Expected error %v. Got %v", c.code, w.Body.String())
+				}
+				return
+			}
+
+			if resp.Result != "user" {
+				t.Errorf("Unexpected result. Expected %v. Got %v", "user", w.Body.String())
+			}
+		})
+	}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func encodeToken(t *testing.T, header interface{}, claims interface{}) string {
+	h, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b64(h) + "." + b64(c)
+}
+
+func signHS256(t *testing.T, kid string, secret []byte, claims interface{}) string {
+	input := encodeToken(t, map[string]string{"alg": "HS256", "kid": kid}, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+
+	return input + "." + b64(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, kid string, key *rsa.PrivateKey, claims interface{}) string {
+	input := encodeToken(t, map[string]string{"alg": "RS256", "kid": kid}, claims)
+	digest := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return input + "." + b64(signature)
+}
+
+func signES256(t *testing.T, kid string, key *ecdsa.PrivateKey, claims interface{}) string {
+	input := encodeToken(t, map[string]string{"alg": "ES256", "kid": kid}, claims)
+	digest := sha256.Sum256([]byte(input))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	return input + "." + b64(signature)
+}
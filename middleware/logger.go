@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+// redacted replaces values of redacted params fields.
+const redacted = "[REDACTED]"
+
+// AccessRecord is a structured record of a single JSON-RPC call.
+type AccessRecord struct {
+	Method string
+	ID     string
+	// RequestID is an HTTP request id.
+	RequestID string
+	// CorrelationID is a call correlation id, it differs from RequestID for calls in batch.
+	CorrelationID string
+	// Duration is a call handling time, including inner middlewares.
+	Duration time.Duration
+	// ErrorCode is a JSON-RPC error code, 0 if call succeeded.
+	ErrorCode  int
+	ParamsSize int
+	BatchIndex int
+	// BatchSize is a number of calls in batch, 0 if call is not a part of batch.
+	BatchSize  int
+	RemoteAddr string
+	// Params are call params with redacted fields, nil if LoggerOptions.LogParams is false.
+	Params []byte
+}
+
+// AccessLogger writes access records.
+type AccessLogger interface {
+	Log(ctx context.Context, record AccessRecord)
+}
+
+type LoggerOptions struct {
+	// Logger writes access records.
+	Logger AccessLogger
+	// LogParams enables params logging.
+	LogParams bool
+	// Redact is a list of params paths, which values are replaced in logs, e.g. "password", "user.token" or
+	// "cards.*.number". Path segments are object keys or array indexes, "*" matches any key or index.
+	Redact []string
+}
+
+// Logger writes access record for every call.
+func Logger(opts LoggerOptions) jsonrpc.MiddlewareFunc {
+	if opts.Logger == nil {
+		panic("access logger is required")
+	}
+
+	redact := make([][]string, 0, len(opts.Redact))
+	for _, path := range opts.Redact {
+		redact = append(redact, strings.Split(path, "."))
+	}
+
+	return func(next jsonrpc.Handler) jsonrpc.Handler {
+		return func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+			start := time.Now()
+
+			result, err := next(ctx)
+
+			record := AccessRecord{
+				Method:        ctx.Method,
+				ID:            ctx.ID,
+				RequestID:     ctx.RequestID,
+				CorrelationID: ctx.CorrelationID,
+				Duration:      time.Since(start),
+				ParamsSize:    len(ctx.Params),
+				BatchIndex:    ctx.BatchIndex,
+				BatchSize:     ctx.BatchSize,
+				RemoteAddr:    ctx.R.RemoteAddr,
+			}
+
+			if err != nil {
+				record.ErrorCode = err.Code()
+			}
+
+			if opts.LogParams && len(ctx.Params) > 0 {
+				record.Params = redactParams(ctx.Params, redact)
+			}
+
+			opts.Logger.Log(ctx.Context(), record)
+
+			return result, err
+		}
+	}
+}
+
+// redactParams replace values of params paths. If params can not be decoded, they are fully redacted.
+func redactParams(params []byte, paths [][]string) []byte {
+	if len(paths) == 0 {
+		return params
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(params))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return []byte(strconv.Quote(redacted))
+	}
+
+	for _, path := range paths {
+		v = redactPath(v, path)
+	}
+
+	result, err := json.Marshal(v)
+	if err != nil {
+		return []byte(strconv.Quote(redacted))
+	}
+
+	return result
+}
+
+func redactPath(v interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return redacted
+	}
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			if path[0] == "*" || path[0] == key {
+				value[key] = redactPath(item, path[1:])
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			if path[0] == "*" || path[0] == strconv.Itoa(i) {
+				value[i] = redactPath(item, path[1:])
+			}
+		}
+	}
+
+	return v
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// SlogLogger returns access logger, which writes records to slog logger. Successful calls are logged with
+// info level, failed calls with warn level.
+func SlogLogger(logger *slog.Logger) AccessLogger {
+	return &slogLogger{logger: logger}
+}
+
+func (l *slogLogger) Log(ctx context.Context, record AccessRecord) {
+	level := slog.LevelInfo
+	if record.ErrorCode != 0 {
+		level = slog.LevelWarn
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", record.Method),
+		slog.String("id", record.ID),
+		slog.String("request_id", record.RequestID),
+		slog.String("correlation_id", record.CorrelationID),
+		slog.Duration("duration", record.Duration),
+		slog.Int("error_code", record.ErrorCode),
+		slog.Int("params_size", record.ParamsSize),
+		slog.Int("batch_index", record.BatchIndex),
+		slog.Int("batch_size", record.BatchSize),
+		slog.String("remote_addr", record.RemoteAddr),
+	}
+
+	if record.Params != nil {
+		attrs = append(attrs, slog.String("params", string(record.Params)))
+	}
+
+	l.logger.LogAttrs(ctx, level, "jsonrpc call", attrs...)
+}
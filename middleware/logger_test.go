@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+type recordingLogger struct {
+	mu      sync.Mutex
+	records []AccessRecord
+}
+
+func (l *recordingLogger) Log(_ context.Context, record AccessRecord) {
+	l.mu.Lock()
+	l.records = append(l.records, record)
+	l.mu.Unlock()
+}
+
+func TestLogger(t *testing.T) {
+	logger := &recordingLogger{}
+
+	rpc := jsonrpc.NewServer(jsonrpc.Options{})
+	rpc.Use(Logger(LoggerOptions{
+		Logger:    logger,
+		LogParams: true,
+		Redact:    []string{"password", "cards.*.number"},
+	}))
+	rpc.Register("login", func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		return nil, jsonrpc.ErrInvalidParamsJSON()
+	})
+
+	params := `{"user":"bob","password":"secret","cards":[{"number":"4242","exp":"12/30"}]}`
+	call(t, rpc, `[{"jsonrpc":"2.0","method":"login","params":`+params+`,"id":"a"}]`)
+
+	if len(logger.records) != 1 {
+		t.Errorf("Unexpected records count. Expected %v. Got %v", 1, len(logger.records))
+		t.FailNow()
+	}
+
+	record := logger.records[0]
+	expected := AccessRecord{
+		Method:     "login",
+		ID:         "a",
+		ErrorCode:  jsonrpc.ErrorCodeInvalidParams,
+		ParamsSize: len(params),
+		BatchIndex: 0,
+		BatchSize:  1,
+		RemoteAddr: "192.0.2.1:1234",
+		Params:     []byte(`{"cards":[{"exp":"12/30","number":"[REDACTED]"}],"password":"[REDACTED]","user":"bob"}`),
+	}
+
+	record.Duration = 0
+	if record.Method != expected.Method || record.ID != expected.ID || record.ErrorCode != expected.ErrorCode ||
+		record.ParamsSize != expected.ParamsSize || record.BatchIndex != expected.BatchIndex ||
+		record.BatchSize != expected.BatchSize || record.RemoteAddr != expected.RemoteAddr ||
+		string(record.Params) != string(expected.Params) {
+		t.Errorf("Unexpected record. Expected %+v. Got %+v", expected, record)
+	}
+
+	if record.RequestID == "" || record.CorrelationID != record.RequestID+"-0" {
+		t.Errorf("Unexpected correlation id. Expected %v. Got %v", record.RequestID+"-0", record.CorrelationID)
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := SlogLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	logger.Log(context.Background(), AccessRecord{Method: "sum", ID: "1", ErrorCode: jsonrpc.ErrorCodeInternal})
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Errorf("Received unexpected error:\n%+v", err)
+		t.FailNow()
+	}
+
+	if entry["level"] != "WARN" || entry["method"] != "sum" || entry["error_code"] != float64(jsonrpc.ErrorCodeInternal) {
+		t.Errorf("Unexpected log entry %v", buf.String())
+	}
+}
@@ -0,0 +1,269 @@
+package middleware
+
+import (
+	"bufio"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+var (
+	// DefaultDurationBuckets are call duration histogram buckets in seconds.
+	DefaultDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// DefaultBatchSizeBuckets are batch size histogram buckets.
+	DefaultBatchSizeBuckets = []float64{1, 2, 5, 10, 20, 50, 100}
+)
+
+type MetricsOptions struct {
+	// Namespace is a metric names prefix, default is "jsonrpc".
+	Namespace string
+	// DurationBuckets are call duration histogram buckets in seconds.
+	DurationBuckets []float64
+	// BatchSizeBuckets are batch size histogram buckets.
+	BatchSizeBuckets []float64
+}
+
+// Metrics collects calls metrics and exposes them in Prometheus text format. Use Middleware to collect metrics
+// and Metrics itself as http.Handler for scraping.
+type Metrics struct {
+	opts MetricsOptions
+
+	mu        sync.Mutex
+	requests  map[string]uint64
+	errors    map[methodCode]uint64
+	durations map[string]*histogram
+	inFlight  map[string]int64
+	batchSize *histogram
+}
+
+type methodCode struct {
+	method string
+	code   int
+}
+
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range h.buckets {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+
+	h.sum += v
+	h.count++
+}
+
+// NewMetrics creates metrics collector.
+func NewMetrics(opts MetricsOptions) *Metrics {
+	if opts.Namespace == "" {
+		opts.Namespace = "jsonrpc"
+	}
+
+	if opts.DurationBuckets == nil {
+		opts.DurationBuckets = DefaultDurationBuckets
+	}
+
+	if opts.BatchSizeBuckets == nil {
+		opts.BatchSizeBuckets = DefaultBatchSizeBuckets
+	}
+
+	return &Metrics{
+		opts:      opts,
+		requests:  make(map[string]uint64),
+		errors:    make(map[methodCode]uint64),
+		durations: make(map[string]*histogram),
+		inFlight:  make(map[string]int64),
+		batchSize: newHistogram(opts.BatchSizeBuckets),
+	}
+}
+
+// Middleware collects calls count, errors by code, duration and in-flight calls by method. Invalid calls and
+// calls of not registered methods do not reach middlewares, use Hooks to count them and batch sizes.
+func (m *Metrics) Middleware() jsonrpc.MiddlewareFunc {
+	return func(next jsonrpc.Handler) jsonrpc.Handler {
+		return func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+			m.mu.Lock()
+			m.inFlight[ctx.Method]++
+			m.mu.Unlock()
+
+			// handler can panic without Recovery middleware
+			defer func() {
+				m.mu.Lock()
+				m.inFlight[ctx.Method]--
+				m.mu.Unlock()
+			}()
+
+			start := time.Now()
+
+			result, err := next(ctx)
+
+			duration := time.Since(start).Seconds()
+
+			m.mu.Lock()
+			m.requests[ctx.Method]++
+			if err != nil {
+				m.errors[methodCode{method: ctx.Method, code: err.Code()}]++
+			}
+			h, ok := m.durations[ctx.Method]
+			if !ok {
+				h = newHistogram(m.opts.DurationBuckets)
+				m.durations[ctx.Method] = h
+			}
+			h.observe(duration)
+			m.mu.Unlock()
+
+			return result, err
+		}
+	}
+}
+
+// Hooks returns server hooks, which count batch sizes and errors of calls, which do not reach middlewares:
+// invalid requests and calls of not registered methods. These errors are counted with empty method label, as
+// method of invalid call is unknown and names of not registered methods are not bounded. Use jsonrpc.ChainHooks
+// to combine them with other hooks.
+func (m *Metrics) Hooks() jsonrpc.Hooks {
+	return jsonrpc.Hooks{
+		OnBatch: func(e jsonrpc.BatchEvent) {
+			m.mu.Lock()
+			m.batchSize.observe(float64(len(e.Calls)))
+			m.mu.Unlock()
+		},
+		OnParseError: func(e jsonrpc.ParseErrorEvent) {
+			m.countError(e.Err.Code())
+		},
+		OnMethodNotFound: func(jsonrpc.MethodNotFoundEvent) {
+			m.countError(jsonrpc.ErrorCodeMethodNotFound)
+		},
+	}
+}
+
+// countError counts error of call, which does not reach middlewares.
+func (m *Metrics) countError(code int) {
+	m.mu.Lock()
+	m.errors[methodCode{code: code}]++
+	m.mu.Unlock()
+}
+
+// ServeHTTP writes metrics in Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	bw := bufio.NewWriter(w)
+	m.write(bw)
+	_ = bw.Flush()
+}
+
+func (m *Metrics) write(w *bufio.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ns := m.opts.Namespace
+
+	writeHeader(w, ns+"_requests_total", "Total number of calls.", "counter")
+	for _, method := range sortedKeys(m.requests) {
+		writeSample(w, ns+"_requests_total", labels("method", method), float64(m.requests[method]))
+	}
+
+	writeHeader(w, ns+"_errors_total", "Total number of failed calls by error code.", "counter")
+	errorKeys := make([]methodCode, 0, len(m.errors))
+	for key := range m.errors {
+		errorKeys = append(errorKeys, key)
+	}
+	sort.Slice(errorKeys, func(i, j int) bool {
+		if errorKeys[i].method != errorKeys[j].method {
+			return errorKeys[i].method < errorKeys[j].method
+		}
+		return errorKeys[i].code < errorKeys[j].code
+	})
+	for _, key := range errorKeys {
+		writeSample(w, ns+"_errors_total", labels("method", key.method, "code", strconv.Itoa(key.code)), float64(m.errors[key]))
+	}
+
+	writeHeader(w, ns+"_request_duration_seconds", "Call duration in seconds.", "histogram")
+	for _, method := range sortedKeys(m.durations) {
+		writeHistogram(w, ns+"_request_duration_seconds", []string{"method", method}, m.durations[method])
+	}
+
+	writeHeader(w, ns+"_in_flight_requests", "Number of calls being processed.", "gauge")
+	for _, method := range sortedKeys(m.inFlight) {
+		writeSample(w, ns+"_in_flight_requests", labels("method", method), float64(m.inFlight[method]))
+	}
+
+	writeHeader(w, ns+"_batch_size", "Number of calls in batch.", "histogram")
+	writeHistogram(w, ns+"_batch_size", nil, m.batchSize)
+}
+
+func writeHeader(w *bufio.Writer, name, help, typ string) {
+	w.WriteString("# HELP " + name + " " + help + "\n")
+	w.WriteString("# TYPE " + name + " " + typ + "\n")
+}
+
+func writeSample(w *bufio.Writer, name, labels string, value float64) {
+	w.WriteString(name)
+	w.WriteString(labels)
+	w.WriteByte(' ')
+	w.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	w.WriteByte('\n')
+}
+
+func writeHistogram(w *bufio.Writer, name string, pairs []string, h *histogram) {
+	for i, bound := range h.buckets {
+		le := strconv.FormatFloat(bound, 'g', -1, 64)
+		writeSample(w, name+"_bucket", labels(append(pairs, "le", le)...), float64(h.counts[i]))
+	}
+	writeSample(w, name+"_bucket", labels(append(pairs, "le", "+Inf")...), float64(h.count))
+	writeSample(w, name+"_sum", labels(pairs...), h.sum)
+	writeSample(w, name+"_count", labels(pairs...), float64(h.count))
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels format label name and value pairs.
+func labels(pairs ...string) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(labelValueReplacer.Replace(pairs[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+
+	return b.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics(MetricsOptions{
+		DurationBuckets:  []float64{60},
+		BatchSizeBuckets: []float64{1, 5},
+	})
+
+	batches := 0
+	rpc := jsonrpc.NewServer(jsonrpc.Options{
+		Hooks: jsonrpc.ChainHooks(metrics.Hooks(), jsonrpc.Hooks{
+			OnBatch: func(e jsonrpc.BatchEvent) { batches++ },
+		}),
+	})
+	rpc.Use(metrics.Middleware())
+	rpc.Register("ok", func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		return ctx.Result(true)
+	})
+	rpc.Register("fail", func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		return nil, jsonrpc.ErrInternalJSON()
+	})
+
+	call(t, rpc, `{"jsonrpc":"2.0","method":"ok","id":1}`)
+	call(t, rpc, `[{"jsonrpc":"2.0","method":"ok","id":1},{"jsonrpc":"2.0","method":"fail","id":2}]`)
+	// batch is counted when its first call does not reach middlewares
+	call(t, rpc, `[{"jsonrpc":"2.0","method":"unknown","id":1},{"jsonrpc":"2.0","method":"ok","id":2},{"jsonrpc":"2.0","method":"ok","id":3}]`)
+	call(t, rpc, `[{"jsonrpc":"1.0","method":"ok","id":1}]`)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/metrics", nil)
+	metrics.ServeHTTP(w, r)
+
+	expected := []string{
+		`# TYPE jsonrpc_requests_total counter`,
+		`jsonrpc_requests_total{method="fail"} 1`,
+		`jsonrpc_requests_total{method="ok"} 4`,
+		`jsonrpc_errors_total{method="",code="-32601"} 1`,
+		`jsonrpc_errors_total{method="",code="-32600"} 1`,
+		`jsonrpc_errors_total{method="fail",code="-32603"} 1`,
+		`# TYPE jsonrpc_request_duration_seconds histogram`,
+		`jsonrpc_request_duration_seconds_bucket{method="ok",le="60"} 4`,
+		`jsonrpc_request_duration_seconds_bucket{method="ok",le="+Inf"} 4`,
+		`jsonrpc_request_duration_seconds_count{method="ok"} 4`,
+		`jsonrpc_in_flight_requests{method="ok"} 0`,
+		`jsonrpc_batch_size_bucket{le="1"} 1`,
+		`jsonrpc_batch_size_bucket{le="5"} 3`,
+		`jsonrpc_batch_size_sum 6`,
+		`jsonrpc_batch_size_count 3`,
+	}
+
+	body := w.Body.String()
+	for _, line := range expected {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected line %q in:\n%v", line, body)
+		}
+	}
+
+	if batches != 3 {
+		t.Errorf("Unexpected result. Expected %v. Got %v", 3, batches)
+	}
+}
+
+func TestMetricsPanic(t *testing.T) {
+	metrics := NewMetrics(MetricsOptions{})
+	handler := metrics.Middleware()(func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		panic("boom")
+	})
+
+	func() {
+		defer func() {
+			_ = recover()
+		}()
+		_, _ = handler(&jsonrpc.RequestCtx{Method: "panic"})
+	}()
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/metrics", nil)
+	metrics.ServeHTTP(w, r)
+
+	line := `jsonrpc_in_flight_requests{method="panic"} 0`
+	if !strings.Contains(w.Body.String(), line+"\n") {
+		t.Errorf("Expected line %q in:\n%v", line, w.Body.String())
+	}
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+// RateLimitStore keeps rate limit state by key.
+type RateLimitStore interface {
+	// Take tries to take n tokens for key. If there are not enough tokens, it returns false and duration
+	// after which call can be retried.
+	Take(key string, n int) (ok bool, retryAfter time.Duration)
+}
+
+type RateLimitOptions struct {
+	// Store keeps limits state, e.g. NewTokenBucketStore.
+	Store RateLimitStore
+	// Key returns rate limit key for call, client IP is used by default.
+	Key func(ctx *jsonrpc.RequestCtx) string
+}
+
+// RateLimit limits calls rate. Every call takes one token, so batch of 10 calls takes 10 tokens. When limit is
+// exceeded, rate limit error is returned with retry_after seconds in error data.
+func RateLimit(opts RateLimitOptions) jsonrpc.MiddlewareFunc {
+	if opts.Store == nil {
+		panic("rate limit store is required")
+	}
+
+	if opts.Key == nil {
+		opts.Key = KeyByIP
+	}
+
+	return func(next jsonrpc.Handler) jsonrpc.Handler {
+		return func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+			ok, retryAfter := opts.Store.Take(opts.Key(ctx), 1)
+			if !ok {
+				err := jsonrpc.ErrRateLimited()
+				err.Data = map[string]interface{}{
+					"retry_after": math.Ceil(retryAfter.Seconds()),
+				}
+
+				return nil, err.JSON()
+			}
+
+			return next(ctx)
+		}
+	}
+}
+
+// KeyByMethod returns method name as rate limit key.
+func KeyByMethod(ctx *jsonrpc.RequestCtx) string {
+	return ctx.Method
+}
+
+// KeyByIP returns client IP address as rate limit key.
+func KeyByIP(ctx *jsonrpc.RequestCtx) string {
+	host, _, err := net.SplitHostPort(ctx.R.RemoteAddr)
+	if err != nil {
+		return ctx.R.RemoteAddr
+	}
+
+	return host
+}
+
+// how often token buckets are checked for removal.
+const sweepInterval = 1024
+
+// TokenBucketStore is in-memory token bucket rate limit store.
+type TokenBucketStore struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	takes   int
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucketStore creates store which allows rate tokens per second with burst capacity for every key.
+func NewTokenBucketStore(rate float64, burst int) *TokenBucketStore {
+	if rate <= 0 || burst <= 0 {
+		panic("rate and burst must be positive")
+	}
+
+	return &TokenBucketStore{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take implements RateLimitStore interface.
+func (s *TokenBucketStore) Take(key string, n int) (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	s.takes++
+	if s.takes%sweepInterval == 0 {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: s.burst, last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(s.burst, b.tokens+now.Sub(b.last).Seconds()*s.rate)
+	b.last = now
+
+	if b.tokens < float64(n) {
+		missing := float64(n) - b.tokens
+		return false, time.Duration(missing / s.rate * float64(time.Second))
+	}
+
+	b.tokens -= float64(n)
+	return true, 0
+}
+
+// sweep remove buckets which are refilled, they are equal to new buckets.
+func (s *TokenBucketStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*s.rate >= s.burst {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+func TestRateLimit(t *testing.T) {
+	store := NewTokenBucketStore(1, 2)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	rpc := jsonrpc.NewServer(jsonrpc.Options{})
+	rpc.Use(RateLimit(RateLimitOptions{Store: store, Key: KeyByMethod}))
+	rpc.Register("ping", func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		return ctx.Result("pong")
+	})
+
+	batch := `[{"jsonrpc":"2.0","method":"ping","id":1},{"jsonrpc":"2.0","method":"ping","id":2},{"jsonrpc":"2.0","method":"ping","id":3}]`
+	responses := call(t, rpc, batch)
+
+	limited := 0
+	for _, resp := range responses {
+		if resp.Error == nil {
+			continue
+		}
+
+		limited++
+		if resp.Error.Code != jsonrpc.ErrorCodeRateLimited {
+			t.Errorf("Unexpected error code. Expected %v. Got %v", jsonrpc.ErrorCodeRateLimited, resp.Error.Code)
+		}
+		if retryAfter := resp.Error.Data.(map[string]interface{})["retry_after"]; retryAfter != 1.0 {
+			t.Errorf("Unexpected retry_after. Expected %v. Got %v", 1, retryAfter)
+		}
+	}
+
+	if limited != 1 {
+		t.Errorf("Unexpected limited calls. Expected %v. Got %v", 1, limited)
+		t.FailNow()
+	}
+
+	now = now.Add(time.Second)
+
+	responses = call(t, rpc, `{"jsonrpc":"2.0","method":"ping","id":4}`)
+	if responses[0].Error != nil {
+		t.Errorf("Received unexpected error:\n%+v", responses[0].Error)
+	}
+}
+
+type response struct {
+	Result interface{}        `json:"result"`
+	Error  *jsonrpc.JRPCError `json:"error"`
+	ID     interface{}        `json:"id"`
+}
+
+// call send request to server and decode single or batch response.
+func call(t *testing.T, rpc http.Handler, in string) []response {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(in))
+	r.Header.Set("Content-Type", "application/json")
+	r.RemoteAddr = "192.0.2.1:1234"
+
+	rpc.ServeHTTP(w, r)
+
+	var responses []response
+	if err := json.Unmarshal(w.Body.Bytes(), &responses); err != nil {
+		var single response
+		if err = json.Unmarshal(w.Body.Bytes(), &single); err != nil {
+			t.Errorf("Received unexpected error:\n%+v", err)
+			t.FailNow()
+		}
+		responses = append(responses, single)
+	}
+
+	return responses
+}
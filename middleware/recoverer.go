@@ -1,19 +1,78 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"log"
+	"runtime/debug"
 
 	"github.com/lapitskyss/jsonrpc"
 )
 
+// PanicInfo describes recovered panic.
+type PanicInfo struct {
+	// Value is a recovered value.
+	Value interface{}
+	// Stack is a goroutine stack trace, nil if RecoveryOptions.Stack is false.
+	Stack []byte
+	// ID is a random panic id, empty if RecoveryOptions.PanicID is false.
+	ID string
+}
+
+type RecoveryOptions struct {
+	// Stack enables stack trace capturing.
+	Stack bool
+	// Handler is called for every recovered panic, e.g. to report error. Panic is logged if handler is nil.
+	Handler func(ctx *jsonrpc.RequestCtx, info PanicInfo)
+	// PanicID enables panic id generation. Id is passed to handler and returned to client in error data
+	// as "panic_id", so it can be quoted in support tickets.
+	PanicID bool
+	// Repanic panics again after handler is called, e.g. in development mode. Panic of batch call is raised
+	// on HTTP handler goroutine after all batch calls are finished.
+	Repanic bool
+}
+
+// Recovery recovers from panics, logs panic value and returns internal error.
 func Recovery() jsonrpc.MiddlewareFunc {
+	return RecoveryWithOptions(RecoveryOptions{})
+}
+
+// RecoveryWithOptions recovers from panics and returns internal error.
+func RecoveryWithOptions(opts RecoveryOptions) jsonrpc.MiddlewareFunc {
 	return func(next jsonrpc.Handler) jsonrpc.Handler {
 		return func(ctx *jsonrpc.RequestCtx) (_ jsonrpc.Result, err jsonrpc.Error) {
 			defer func() {
 				if rvr := recover(); rvr != nil {
-					log.Println(rvr)
+					info := PanicInfo{Value: rvr}
+					if opts.Stack {
+						info.Stack = debug.Stack()
+					}
+					if opts.PanicID {
+						info.ID = newPanicID()
+					}
 
-					err = jsonrpc.ErrInternalJSON()
+					if opts.Handler != nil {
+						opts.Handler(ctx, info)
+					} else if info.Stack != nil {
+						log.Printf("%v\n%s", rvr, info.Stack)
+					} else {
+						log.Println(rvr)
+					}
+
+					if opts.Repanic {
+						panic(rvr)
+					}
+
+					if info.ID == "" {
+						err = jsonrpc.ErrInternalJSON()
+						return
+					}
+
+					internalErr := jsonrpc.ErrInternal()
+					internalErr.Data = map[string]interface{}{
+						"panic_id": info.ID,
+					}
+					err = internalErr.JSON()
 				}
 			}()
 
@@ -21,3 +80,10 @@ func Recovery() jsonrpc.MiddlewareFunc {
 		}
 	}
 }
+
+func newPanicID() string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+
+	return hex.EncodeToString(id[:])
+}
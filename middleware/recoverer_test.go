@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bytes"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+func TestRecovery(t *testing.T) {
+	var recovered PanicInfo
+
+	rpc := jsonrpc.NewServer(jsonrpc.Options{})
+	rpc.Use(RecoveryWithOptions(RecoveryOptions{
+		Stack:   true,
+		PanicID: true,
+		Handler: func(ctx *jsonrpc.RequestCtx, info PanicInfo) {
+			recovered = info
+		},
+	}))
+	rpc.Register("panic", func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		panic("boom")
+	})
+
+	responses := call(t, rpc, `{"jsonrpc":"2.0","method":"panic","id":1}`)
+
+	if recovered.Value != "boom" || !bytes.Contains(recovered.Stack, []byte("TestRecovery")) || len(recovered.ID) != 16 {
+		t.Errorf("Unexpected panic info %+v", recovered)
+		t.FailNow()
+	}
+
+	resp := responses[0]
+	if resp.Error == nil || resp.Error.Code != jsonrpc.ErrorCodeInternal {
+		t.Errorf("Unexpected response %+v", resp)
+		t.FailNow()
+	}
+
+	if panicID := resp.Error.Data.(map[string]interface{})["panic_id"]; panicID != recovered.ID {
+		t.Errorf("Unexpected panic id. Expected %v. Got %v", recovered.ID, panicID)
+	}
+}
+
+func TestRecoveryRepanic(t *testing.T) {
+	handler := RecoveryWithOptions(RecoveryOptions{
+		Repanic: true,
+		Handler: func(ctx *jsonrpc.RequestCtx, info PanicInfo) {},
+	})(func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		panic("boom")
+	})
+
+	defer func() {
+		if rvr := recover(); rvr != "boom" {
+			t.Errorf("Unexpected panic value. Expected %v. Got %v", "boom", rvr)
+		}
+	}()
+
+	_, _ = handler(&jsonrpc.RequestCtx{})
+}
+
+func TestRecoveryRepanicBatch(t *testing.T) {
+	rpc := jsonrpc.NewServer(jsonrpc.Options{})
+	rpc.Use(RecoveryWithOptions(RecoveryOptions{
+		Repanic: true,
+		Handler: func(ctx *jsonrpc.RequestCtx, info PanicInfo) {},
+	}))
+	rpc.Register("panic", func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		panic("boom")
+	})
+
+	var done int32
+	rpc.Register("ok", func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		atomic.AddInt32(&done, 1)
+		return ctx.Result("ok")
+	})
+
+	defer func() {
+		if rvr := recover(); rvr != "boom" {
+			t.Errorf("Unexpected panic value. Expected %v. Got %v", "boom", rvr)
+		}
+
+		if n := atomic.LoadInt32(&done); n != 2 {
+			t.Errorf("Unexpected finished calls. Expected %v. Got %v", 2, n)
+		}
+	}()
+
+	// batch call panics on its own goroutine, panic must be raised on the calling one
+	call(t, rpc, `[{"jsonrpc":"2.0","method":"ok","id":1},{"jsonrpc":"2.0","method":"panic","id":2},{"jsonrpc":"2.0","method":"ok","id":3}]`)
+}
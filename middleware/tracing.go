@@ -0,0 +1,288 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+// TraceparentHeader is a W3C trace context header, see https://www.w3.org/TR/trace-context/.
+const TraceparentHeader = "traceparent"
+
+type (
+	TraceID [16]byte
+	SpanID  [8]byte
+)
+
+// SpanContext identifies span in trace.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid check if trace and span ids are not zero.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Traceparent returns span context as traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+// ParseTraceparent parses traceparent header value.
+func ParseTraceparent(s string) (SpanContext, bool) {
+	var sc SpanContext
+
+	// version-traceid-spanid-flags, future versions may append fields
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return sc, false
+	}
+
+	var version, flags [1]byte
+	if _, err := hex.Decode(version[:], []byte(s[:2])); err != nil || version[0] == 0xff {
+		return sc, false
+	}
+	if version[0] == 0 && len(s) != 55 || len(s) > 55 && s[55] != '-' {
+		return sc, false
+	}
+
+	if _, err := hex.Decode(sc.TraceID[:], []byte(s[3:35])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(s[36:52])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(flags[:], []byte(s[53:55])); err != nil {
+		return sc, false
+	}
+
+	sc.Sampled = flags[0]&1 == 1
+
+	return sc, sc.IsValid()
+}
+
+// Span is a traced operation.
+type Span interface {
+	SpanContext() SpanContext
+	SetAttribute(key string, value interface{})
+	// SetError marks span as failed.
+	SetError(description string)
+	End()
+}
+
+// Tracer starts spans. Parent span context is zero for root spans.
+type Tracer interface {
+	Start(parent SpanContext, name string) Span
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns context with span.
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns span stored in context.
+func SpanFromContext(ctx context.Context) Span {
+	span, _ := ctx.Value(spanKey{}).(Span)
+	return span
+}
+
+// TraceHTTP starts span for every HTTP request, with parent extracted from traceparent header. Calls
+// spans started by Tracing middleware become its children, so batch calls are sibling spans.
+func TraceHTTP(tracer Tracer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parent, _ := ParseTraceparent(r.Header.Get(TraceparentHeader))
+
+		span := tracer.Start(parent, "HTTP "+r.Method)
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.target", r.URL.Path)
+		defer span.End()
+
+		next.ServeHTTP(w, r.WithContext(ContextWithSpan(r.Context(), span)))
+	})
+}
+
+type TracingOptions struct {
+	Tracer Tracer
+	// ParamsKey is a params object member with traceparent value, e.g. "meta". It is used to propagate trace
+	// context over non-HTTP transports, when there is no HTTP span or traceparent header.
+	ParamsKey string
+}
+
+// Tracing starts span named after method for every call. Parent is a span from request context, started by
+// TraceHTTP, otherwise trace context is extracted from traceparent header or params. Call span is available
+// to handlers with SpanFromContext(ctx.Context()).
+func Tracing(opts TracingOptions) jsonrpc.MiddlewareFunc {
+	if opts.Tracer == nil {
+		panic("tracer is required")
+	}
+
+	return func(next jsonrpc.Handler) jsonrpc.Handler {
+		return func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+			span := opts.Tracer.Start(parentSpanContext(ctx, opts.ParamsKey), ctx.Method)
+			defer span.End()
+
+			span.SetAttribute("rpc.system", "jsonrpc")
+			span.SetAttribute("rpc.method", ctx.Method)
+			span.SetAttribute("rpc.jsonrpc.request_id", ctx.ID)
+			span.SetAttribute("http.request_id", ctx.RequestID)
+			span.SetAttribute("correlation_id", ctx.CorrelationID)
+			if ctx.BatchSize > 0 {
+				span.SetAttribute("rpc.jsonrpc.batch_index", ctx.BatchIndex)
+			}
+
+			ctx.R = ctx.R.WithContext(ContextWithSpan(ctx.Context(), span))
+
+			result, err := next(ctx)
+			if err != nil {
+				span.SetAttribute("rpc.jsonrpc.error_code", err.Code())
+				span.SetError(string(err))
+			}
+
+			return result, err
+		}
+	}
+}
+
+func parentSpanContext(ctx *jsonrpc.RequestCtx, paramsKey string) SpanContext {
+	if span := SpanFromContext(ctx.Context()); span != nil {
+		return span.SpanContext()
+	}
+
+	if sc, ok := ParseTraceparent(ctx.R.Header.Get(TraceparentHeader)); ok {
+		return sc
+	}
+
+	if paramsKey != "" {
+		var params map[string]json.RawMessage
+		if json.Unmarshal(ctx.Params, &params) == nil {
+			var traceparent string
+			if json.Unmarshal(params[paramsKey], &traceparent) == nil {
+				sc, _ := ParseTraceparent(traceparent)
+				return sc
+			}
+		}
+	}
+
+	return SpanContext{}
+}
+
+// SpanData is a finished span recorded by InMemoryTracer.
+type SpanData struct {
+	Name       string
+	Context    SpanContext
+	Parent     SpanContext
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]interface{}
+	Error      string
+}
+
+// InMemoryTracer records finished spans in memory. It is useful for tests and as a reference for exporters.
+type InMemoryTracer struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// NewInMemoryTracer creates in-memory tracer.
+func NewInMemoryTracer() *InMemoryTracer {
+	return &InMemoryTracer{}
+}
+
+// Start implements Tracer interface.
+func (t *InMemoryTracer) Start(parent SpanContext, name string) Span {
+	sc := SpanContext{
+		TraceID: parent.TraceID,
+		Sampled: parent.Sampled,
+	}
+
+	if !parent.IsValid() {
+		_, _ = rand.Read(sc.TraceID[:])
+		sc.Sampled = true
+	}
+	_, _ = rand.Read(sc.SpanID[:])
+
+	return &memorySpan{
+		tracer: t,
+		data: SpanData{
+			Name:       name,
+			Context:    sc,
+			Parent:     parent,
+			Start:      time.Now(),
+			Attributes: make(map[string]interface{}),
+		},
+	}
+}
+
+// Spans returns finished spans.
+func (t *InMemoryTracer) Spans() []SpanData {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]SpanData(nil), t.spans...)
+}
+
+// Reset removes recorded spans.
+func (t *InMemoryTracer) Reset() {
+	t.mu.Lock()
+	t.spans = nil
+	t.mu.Unlock()
+}
+
+type memorySpan struct {
+	tracer *InMemoryTracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+func (s *memorySpan) SpanContext() SpanContext {
+	return s.data.Context
+}
+
+func (s *memorySpan) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	if !s.ended {
+		s.data.Attributes[key] = value
+	}
+	s.mu.Unlock()
+}
+
+func (s *memorySpan) SetError(description string) {
+	s.mu.Lock()
+	if !s.ended {
+		s.data.Error = description
+	}
+	s.mu.Unlock()
+}
+
+func (s *memorySpan) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.tracer.mu.Lock()
+	s.tracer.spans = append(s.tracer.spans, data)
+	s.tracer.mu.Unlock()
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lapitskyss/jsonrpc"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	var tc = []struct {
+		in    string
+		valid bool
+	}{
+		{in: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", valid: true},
+		{in: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", valid: true},
+		{in: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{in: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{in: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{in: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{in: "00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01"},
+		{in: ""},
+	}
+
+	for _, c := range tc {
+		sc, ok := ParseTraceparent(c.in)
+		if ok != c.valid {
+			t.Errorf("Unexpected result for %q. Expected %v. Got %v", c.in, c.valid, ok)
+			continue
+		}
+
+		if ok && c.in[:2] == "00" && sc.Traceparent() != c.in {
+			t.Errorf("Unexpected traceparent. Expected %v. Got %v", c.in, sc.Traceparent())
+		}
+	}
+}
+
+func TestTracing(t *testing.T) {
+	tracer := NewInMemoryTracer()
+
+	rpc := jsonrpc.NewServer(jsonrpc.Options{})
+	rpc.Use(Tracing(TracingOptions{Tracer: tracer, ParamsKey: "meta"}))
+	rpc.Register("ok", func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		SpanFromContext(ctx.Context()).SetAttribute("handler", true)
+		return ctx.Result(true)
+	})
+	rpc.Register("fail", func(ctx *jsonrpc.RequestCtx) (jsonrpc.Result, jsonrpc.Error) {
+		return nil, jsonrpc.ErrInternalJSON()
+	})
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	parent, _ := ParseTraceparent(traceparent)
+
+	t.Run("HTTP", func(t *testing.T) {
+		tracer.Reset()
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`[{"jsonrpc":"2.0","method":"ok","id":1},{"jsonrpc":"2.0","method":"fail","id":2}]`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set(TraceparentHeader, traceparent)
+		r.Header.Set("X-Request-ID", "req")
+
+		TraceHTTP(tracer, rpc).ServeHTTP(w, r)
+
+		spans := tracer.Spans()
+		if len(spans) != 3 {
+			t.Errorf("Unexpected spans count. Expected %v. Got %v", 3, len(spans))
+			t.FailNow()
+		}
+
+		httpSpan := spans[2]
+		if httpSpan.Name != "HTTP POST" || httpSpan.Parent != parent {
+			t.Errorf("Unexpected HTTP span %+v", httpSpan)
+		}
+
+		for _, span := range spans[:2] {
+			if span.Parent != httpSpan.Context || span.Context.TraceID != parent.TraceID {
+				t.Errorf("Call span %v is not a child of HTTP span", span.Name)
+			}
+
+			switch span.Name {
+			case "ok":
+				if span.Attributes["handler"] != true || span.Attributes["correlation_id"] != "req-0" || span.Error != "" {
+					t.Errorf("Unexpected span %+v", span)
+				}
+			case "fail":
+				if span.Attributes["rpc.jsonrpc.error_code"] != jsonrpc.ErrorCodeInternal || span.Attributes["correlation_id"] != "req-1" ||
+					span.Error == "" {
+					t.Errorf("Unexpected span %+v", span)
+				}
+			default:
+				t.Errorf("Unexpected span %v", span.Name)
+			}
+		}
+	})
+
+	t.Run("Params", func(t *testing.T) {
+		tracer.Reset()
+
+		call(t, rpc, `{"jsonrpc":"2.0","method":"ok","params":{"meta":"`+traceparent+`"},"id":1}`)
+
+		spans := tracer.Spans()
+		if len(spans) != 1 || spans[0].Parent != parent {
+			t.Errorf("Unexpected spans %+v", spans)
+		}
+	})
+}
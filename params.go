@@ -0,0 +1,256 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/lapitskyss/jsonrpc/jparser"
+)
+
+var (
+	ErrParamNotFound = errors.New("param not found")
+	ErrParamType     = errors.New("param has unexpected type")
+)
+
+// Value is a view of params JSON value. It refers to request data and does not copy it.
+type Value struct {
+	raw []byte
+	typ jparser.ValueType
+	err error
+}
+
+// Param returns params value by path of object keys and array indexes, e.g. ctx.Param("user", "id") or
+// ctx.Param("items", "[0]"). Params are not decoded, value is found by scanning params data.
+func (ctx *RequestCtx) Param(keys ...string) Value {
+	return newValue(ctx.Params, keys...)
+}
+
+// ParamAt returns positional param with index.
+func (ctx *RequestCtx) ParamAt(index int) Value {
+	return ctx.Param(indexKey(index))
+}
+
+// ParamString returns string param by path. Invalid params error with param path in data is returned if param
+// is missing or is not a string.
+func (ctx *RequestCtx) ParamString(keys ...string) (string, Error) {
+	s, err := ctx.Param(keys...).String()
+	if err != nil {
+		return "", errParam(keys, err)
+	}
+
+	return s, nil
+}
+
+// ParamInt returns integer param by path. Invalid params error with param path in data is returned if param
+// is missing or is not an integer.
+func (ctx *RequestCtx) ParamInt(keys ...string) (int64, Error) {
+	n, err := ctx.Param(keys...).Int()
+	if err != nil {
+		return 0, errParam(keys, err)
+	}
+
+	return n, nil
+}
+
+// ParamFloat returns number param by path. Invalid params error with param path in data is returned if param
+// is missing or is not a number.
+func (ctx *RequestCtx) ParamFloat(keys ...string) (float64, Error) {
+	f, err := ctx.Param(keys...).Float()
+	if err != nil {
+		return 0, errParam(keys, err)
+	}
+
+	return f, nil
+}
+
+// ParamBool returns boolean param by path. Invalid params error with param path in data is returned if param
+// is missing or is not a boolean.
+func (ctx *RequestCtx) ParamBool(keys ...string) (bool, Error) {
+	b, err := ctx.Param(keys...).Bool()
+	if err != nil {
+		return false, errParam(keys, err)
+	}
+
+	return b, nil
+}
+
+func newValue(data []byte, keys ...string) Value {
+	if len(data) == 0 {
+		return Value{err: ErrParamNotFound}
+	}
+
+	raw, typ, err := jparser.Get(data, keys...)
+	if err == jparser.KeyPathNotFoundError {
+		err = ErrParamNotFound
+	}
+
+	return Value{raw: raw, typ: typ, err: err}
+}
+
+func indexKey(index int) string {
+	return "[" + strconv.Itoa(index) + "]"
+}
+
+// Exists check if value exists.
+func (v Value) Exists() bool {
+	return v.err == nil
+}
+
+// Err returns error of value lookup, ErrParamNotFound if value does not exist.
+func (v Value) Err() error {
+	return v.err
+}
+
+// Type returns JSON type of value.
+func (v Value) Type() jparser.ValueType {
+	return v.typ
+}
+
+// Raw returns raw JSON value.
+func (v Value) Raw() []byte {
+	return v.raw
+}
+
+// Get returns nested value by path.
+func (v Value) Get(keys ...string) Value {
+	if v.err != nil {
+		return v
+	}
+
+	return newValue(v.raw, keys...)
+}
+
+// Index returns array item with index.
+func (v Value) Index(index int) Value {
+	return v.Get(indexKey(index))
+}
+
+// String returns unescaped string value.
+func (v Value) String() (string, error) {
+	if v.err != nil {
+		return "", v.err
+	}
+
+	str, err := jparser.ParseString(v.raw)
+	return str, paramErr(err)
+}
+
+// Int returns integer value. Numbers with fraction or exponent are not integers.
+func (v Value) Int() (int64, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+
+	n, err := jparser.ParseInt(v.raw)
+	return n, paramErr(err)
+}
+
+// Float returns number value.
+func (v Value) Float() (float64, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+
+	f, err := jparser.ParseFloat(v.raw)
+	return f, paramErr(err)
+}
+
+// Bool returns boolean value.
+func (v Value) Bool() (bool, error) {
+	if v.err != nil {
+		return false, v.err
+	}
+
+	b, err := jparser.ParseBool(v.raw)
+	return b, paramErr(err)
+}
+
+// IsNull check if value is null.
+func (v Value) IsNull() bool {
+	return v.typ == jparser.Null
+}
+
+// Decode decodes value with standard encoding/json package.
+func (v Value) Decode(dst interface{}) error {
+	if v.err != nil {
+		return v.err
+	}
+
+	return json.Unmarshal(v.raw, dst)
+}
+
+// ArrayEach calls f for every array item, until f returns error.
+func (v Value) ArrayEach(f func(index int, item Value) error) error {
+	if v.err != nil {
+		return v.err
+	}
+
+	if v.typ != jparser.Array {
+		return ErrParamType
+	}
+
+	index := 0
+	return jparser.ArrayEach(v.raw, func(value []byte, dataType jparser.ValueType) error {
+		err := f(index, Value{raw: value, typ: dataType})
+		index++
+		return err
+	})
+}
+
+// ObjectEach calls f for every object member, until f returns error. Key must not be modified.
+func (v Value) ObjectEach(f func(key []byte, member Value) error) error {
+	if v.err != nil {
+		return v.err
+	}
+
+	if v.typ != jparser.Object {
+		return ErrParamType
+	}
+
+	return jparser.ObjectEach(v.raw, func(key []byte, value []byte, dataType jparser.ValueType) error {
+		return f(key, Value{raw: value, typ: dataType})
+	})
+}
+
+// paramErr converts jparser type error to ErrParamType.
+func paramErr(err error) error {
+	if err == jparser.ErrUnexpectedType {
+		return ErrParamType
+	}
+
+	return err
+}
+
+// errParam returns invalid params error with param path and reason in data.
+func errParam(keys []string, err error) Error {
+	reason := "invalid value"
+	switch err {
+	case ErrParamNotFound:
+		reason = "not found"
+	case ErrParamType:
+		reason = "unexpected type"
+	}
+
+	e := ErrInvalidParams()
+	e.Data = map[string]interface{}{
+		"param":  paramPath(keys),
+		"reason": reason,
+	}
+
+	return e.JSON()
+}
+
+// paramPath formats params path, e.g. "items[0].id".
+func paramPath(keys []string) string {
+	var b strings.Builder
+	for i, key := range keys {
+		if i > 0 && !strings.HasPrefix(key, "[") {
+			b.WriteByte('.')
+		}
+		b.WriteString(key)
+	}
+
+	return b.String()
+}
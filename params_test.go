@@ -0,0 +1,132 @@
+package jsonrpc
+
+import (
+	"testing"
+)
+
+func TestParam(t *testing.T) {
+	ctx := &RequestCtx{
+		Params: []byte(`{"user":{"id":42,"na\u006de":"Jörg","admin":false,"score":9.5},"items":[{"id":1},{"id":2}]}`),
+	}
+
+	if id, err := ctx.Param("user", "id").Int(); err != nil || id != 42 {
+		t.Errorf("Unexpected result. Expected %v. Got %v %v", 42, id, err)
+	}
+
+	if name, err := ctx.ParamString("user", "name"); err != nil || name != "Jörg" {
+		t.Errorf("Unexpected result. Expected %v. Got %v %s", "Jörg", name, err)
+	}
+
+	if admin, err := ctx.ParamBool("user", "admin"); err != nil || admin {
+		t.Errorf("Unexpected result. Expected %v. Got %v %s", false, admin, err)
+	}
+
+	if score, err := ctx.ParamFloat("user", "score"); err != nil || score != 9.5 {
+		t.Errorf("Unexpected result. Expected %v. Got %v %s", 9.5, score, err)
+	}
+
+	if id, err := ctx.Param("items").Index(1).Get("id").Int(); err != nil || id != 2 {
+		t.Errorf("Unexpected result. Expected %v. Got %v %v", 2, id, err)
+	}
+
+	var ids []int64
+	err := ctx.Param("items").ArrayEach(func(index int, item Value) error {
+		id, err := item.Get("id").Int()
+		ids = append(ids, id)
+		return err
+	})
+	if err != nil || len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("Unexpected result. Expected %v. Got %v %v", []int64{1, 2}, ids, err)
+	}
+
+	var keys []string
+	err = ctx.Param("user").ObjectEach(func(key []byte, member Value) error {
+		keys = append(keys, string(key))
+		return nil
+	})
+	if err != nil || len(keys) != 4 {
+		t.Errorf("Unexpected result. Expected %v keys. Got %v %v", 4, keys, err)
+	}
+
+	var user struct {
+		ID int `json:"id"`
+	}
+	if err = ctx.Param("user").Decode(&user); err != nil || user.ID != 42 {
+		t.Errorf("Unexpected result. Expected %v. Got %v %v", 42, user.ID, err)
+	}
+
+	items, obj := ctx.Param("items"), ctx.Param("user")
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = items.ArrayEach(func(int, Value) error { return nil })
+		_ = obj.ObjectEach(func([]byte, Value) error { return nil })
+	})
+	if allocs != 0 {
+		t.Errorf("Unexpected allocations. Expected %v. Got %v", 0, allocs)
+	}
+}
+
+func TestParamErrors(t *testing.T) {
+	ctx := &RequestCtx{
+		Params: []byte(`[1.5, "a", {"id":1}]`),
+	}
+
+	if n, err := ctx.ParamAt(0).Float(); err != nil || n != 1.5 {
+		t.Errorf("Unexpected result. Expected %v. Got %v %v", 1.5, n, err)
+	}
+
+	if _, err := ctx.ParamAt(0).Int(); err != ErrParamType {
+		t.Errorf("Unexpected error. Expected %v. Got %v", ErrParamType, err)
+	}
+
+	if ctx.ParamAt(3).Exists() || ctx.ParamAt(3).Err() != ErrParamNotFound {
+		t.Errorf("Unexpected result. Expected %v. Got %v", ErrParamNotFound, ctx.ParamAt(3).Err())
+	}
+
+	var tc = []struct {
+		name string
+		err  Error
+		out  string
+	}{
+		{
+			name: "NotFound",
+			err:  second(ctx.ParamInt("[2]", "user")),
+			out:  `{"code":-32602,"message":"Invalid params","data":{"param":"[2].user","reason":"not found"}}`,
+		},
+		{
+			name: "Type",
+			err:  second(ctx.ParamInt("[1]")),
+			out:  `{"code":-32602,"message":"Invalid params","data":{"param":"[1]","reason":"unexpected type"}}`,
+		},
+		{
+			name: "NoParams",
+			err:  second((&RequestCtx{}).ParamString("name")),
+			out:  `{"code":-32602,"message":"Invalid params","data":{"param":"name","reason":"not found"}}`,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			if !IsJSONEqual(c.out, string(c.err)) {
+				t.Errorf("Unexpected result. Expected %v. Got %v", c.out, string(c.err))
+			}
+		})
+	}
+}
+
+func second(_ interface{}, err Error) Error {
+	return err
+}
+
+func BenchmarkParam(b *testing.B) {
+	ctx := &RequestCtx{
+		Params: []byte(`{"user":{"id":42,"name":"John","tags":["a","b","c"],"address":{"city":"Berlin"}},"limit":10}`),
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = ctx.Param("user", "address", "city").String()
+		_, _ = ctx.ParamInt("limit")
+	}
+}
@@ -0,0 +1,53 @@
+package jsonrpc
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+)
+
+// maxRequestIDLen is a max length of request id propagated from request header.
+const maxRequestIDLen = 128
+
+// requestID returns request id from request header or generates a new one.
+func (s *Server) requestID(r *http.Request) string {
+	id := r.Header.Get(s.options.RequestIDHeader)
+	if validRequestID(id) {
+		return id
+	}
+
+	return s.options.RequestIDGenerator()
+}
+
+// validRequestID check if request id is not empty, not too long and contains only printable ASCII characters
+// without spaces, so it is safe to write it to logs and headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+
+	return hex.EncodeToString(id[:])
+}
+
+// correlationID returns call correlation id, which is a request id with batch index suffix for call in batch.
+func correlationID(requestID string, batchIndex, batchSize int) string {
+	if batchSize == 0 {
+		return requestID
+	}
+
+	return requestID + "-" + strconv.Itoa(batchIndex)
+}
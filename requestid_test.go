@@ -0,0 +1,101 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	var mu sync.Mutex
+	var ids []string
+
+	rpc := NewServer(Options{
+		RequestIDGenerator: func() string { return "generated" },
+	})
+	rpc.Register("ping", func(ctx *RequestCtx) (Result, Error) {
+		mu.Lock()
+		ids = append(ids, ctx.RequestID+" "+ctx.CorrelationID)
+		mu.Unlock()
+		return ctx.Result("pong")
+	})
+
+	var tc = []struct {
+		name, in, header, requestID string
+		ids                         []string
+	}{
+		{
+			name:      "Propagated",
+			in:        `{"jsonrpc":"2.0","method":"ping","id":1}`,
+			header:    "abc-123",
+			requestID: "abc-123",
+			ids:       []string{"abc-123 abc-123"},
+		},
+		{
+			name:      "Generated",
+			in:        `{"jsonrpc":"2.0","method":"ping","id":1}`,
+			requestID: "generated",
+			ids:       []string{"generated generated"},
+		},
+		{
+			name:      "Invalid",
+			in:        `{"jsonrpc":"2.0","method":"ping","id":1}`,
+			header:    "abc 123",
+			requestID: "generated",
+			ids:       []string{"generated generated"},
+		},
+		{
+			name:      "TooLong",
+			in:        `{"jsonrpc":"2.0","method":"ping","id":1}`,
+			header:    strings.Repeat("a", maxRequestIDLen+1),
+			requestID: "generated",
+			ids:       []string{"generated generated"},
+		},
+		{
+			name:      "Batch",
+			in:        `[{"jsonrpc":"2.0","method":"ping","id":1},{"jsonrpc":"2.0","method":"ping","id":2}]`,
+			header:    "abc",
+			requestID: "abc",
+			ids:       []string{"abc abc-0", "abc abc-1"},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			ids = nil
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(c.in))
+			r.Header.Set("Content-Type", "application/json")
+			if c.header != "" {
+				r.Header.Set("X-Request-ID", c.header)
+			}
+
+			rpc.ServeHTTP(w, r)
+
+			if requestID := w.Header().Get("X-Request-ID"); requestID != c.requestID {
+				t.Errorf("Unexpected request id header. Expected %v. Got %v", c.requestID, requestID)
+			}
+
+			sort.Strings(ids)
+			if strings.Join(ids, ",") != strings.Join(c.ids, ",") {
+				t.Errorf("Unexpected result. Expected %v. Got %v", c.ids, ids)
+			}
+		})
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	id := newRequestID()
+	if len(id) != 16 || !validRequestID(id) {
+		t.Errorf("Unexpected request id %v", id)
+	}
+
+	if id == newRequestID() {
+		t.Errorf("Request ids are not unique")
+	}
+}
@@ -3,67 +3,49 @@ package jsonrpc
 import (
 	"bytes"
 	"net/http"
+
+	"github.com/lapitskyss/jsonrpc/jparser"
 )
 
 type Result []byte
 
-// send result from server.
-func send(w http.ResponseWriter, result []byte) {
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write(result)
-}
-
-// sendParseError return parse error from server.
-func sendParseError(w http.ResponseWriter) {
-	send(w, []byte(`{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`))
-}
-
-// sendInvalidRequest return invalid request from server.
-func sendInvalidRequest(w http.ResponseWriter) {
-	send(w, []byte(`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`))
-}
-
-// sendMethodNotFound return method not found from server.
-func sendMethodNotFound(w http.ResponseWriter) {
-	send(w, []byte(`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":null}`))
-}
-
-// sendInvalidParams return invalid params error from server.
-func sendInvalidParams(w http.ResponseWriter) {
-	send(w, []byte(`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":null}`))
-}
-
-// sendInternalError return internal error from server.
-func sendInternalError(w http.ResponseWriter) {
-	send(w, []byte(`{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":null}`))
-}
+var nullID = []byte("null")
 
-// sendMaxBatchRequestsError return max batch length exceeded error from server.
-func sendMaxBatchRequestsError(w http.ResponseWriter) {
-	send(w, []byte(`{"jsonrpc":"2.0","error":{"code":-32604,"message":"Max batch length exceeded"},"id":null}`))
+// responseFormat is a negotiated representation of response.
+type responseFormat struct {
+	codec    *codecEntry
+	encoding *encodingEntry
 }
 
-// responseMethodNotFound create method not found error response.
-func responseMethodNotFound(id []byte) []byte {
-	var buffer bytes.Buffer
-
-	buffer.WriteString(`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":`)
-	buffer.Write(id)
-	buffer.WriteString("}")
-
-	return buffer.Bytes()
-}
-
-// responseInvalidRequest create invalid request error response.
-func responseInvalidRequest(id []byte) []byte {
-	var buffer bytes.Buffer
-
-	buffer.WriteString(`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":`)
-	buffer.Write(id)
-	buffer.WriteString("}")
-
-	return buffer.Bytes()
+// send result from server encoded with response codec and compressed with response encoding.
+func (s *Server) send(w http.ResponseWriter, format responseFormat, result []byte) {
+	encoded, err := format.codec.codec.Encode(result)
+	if err != nil {
+		encoded, err = format.codec.codec.Encode(responseError(nullID, ErrInternalJSON()))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if len(s.encodings) > 0 && s.options.CompressMinSize >= 0 {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+
+	if format.encoding != nil && len(encoded) >= s.options.CompressMinSize {
+		if compressed, err := compress(format.encoding, encoded); err == nil {
+			w.Header().Set("Content-Encoding", format.encoding.name)
+			encoded = compressed
+		}
+	}
+
+	if s.isShuttingDown() {
+		w.Header().Set("Connection", "close")
+	}
+
+	w.Header().Set("Content-Type", format.codec.responseType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(encoded)
 }
 
 // responseError create response error with request ID and error.
@@ -91,3 +73,12 @@ func responseResult(id []byte, result []byte) []byte {
 
 	return buffer.Bytes()
 }
+
+// responseID returns parsed request id, null if request has no id.
+func responseID(p *jparser.JParser) []byte {
+	if p.IDType == jparser.NotExist {
+		return nullID
+	}
+
+	return p.ID
+}
@@ -1,8 +1,17 @@
 package jsonrpc
 
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
 const (
 	Version            = "2.0"
 	defaultBatchMaxLen = 10
+	defaultMaxBodySize = 10 << 20
+	defaultCompressMin = 1024
+	defaultRequestID   = "X-Request-ID"
 	contentTypeJSON    = "application/json"
 )
 
@@ -12,35 +21,126 @@ type (
 )
 
 type Server struct {
-	options     Options
-	services    []*Service
-	middlewares []MiddlewareFunc
+	options          Options
+	services         map[string]*Service
+	mounts           []mount
+	middlewares      []MiddlewareFunc
+	batchMiddlewares []BatchMiddlewareFunc
+	codecs           []*codecEntry
+	encodings        []*encodingEntry
+
+	mu           sync.Mutex
+	shuttingDown bool
+	inFlight     sync.WaitGroup
+	ctx          context.Context
+	cancel       context.CancelFunc
 }
 
 type Service struct {
 	name        string
 	handler     Handler
 	middlewares []MiddlewareFunc
+	scopes      []string
+	group       *Group
 }
 
 type Options struct {
 	BatchMaxLen int
+	// ContentType is accepted JSON request media type. Deprecated: use ContentTypes.
 	ContentType string
+	// ContentTypes is a list of accepted JSON request media types, e.g. application/json-rpc.
+	ContentTypes []string
+	// MaxBodySize is a max size of request body in bytes, limit applies to decompressed body too.
+	MaxBodySize int64
+	// CompressMinSize is a min size of response in bytes to be compressed, negative value disables compression.
+	// gzip and deflate are supported by default, other codings, e.g. zstd, are added with Server.RegisterEncoding.
+	CompressMinSize int
+	// ShutdownError is a json error returned for calls received after Shutdown.
+	ShutdownError Error
+	// Strict enables strict request validation: requests with unknown or duplicate members, params which are not
+	// an object or an array, or methods starting with "rpc." get invalid request error with violated rule in
+	// error data.
+	Strict bool
+	// RequestIDHeader is a request and response header with request id, default is "X-Request-ID".
+	RequestIDHeader string
+	// RequestIDGenerator generates request id when request has no valid request id header. Random 16 hex
+	// characters id is generated by default.
+	RequestIDGenerator func() string
+	// RejectFractionalID enables rejection of requests with number id with fractional part or exponent, which
+	// should not be used according to specification, with invalid request error.
+	RejectFractionalID bool
+	// DuplicateID is a policy for batch calls with the same id, duplicate ids are allowed by default.
+	DuplicateID DuplicateIDPolicy
+	// Hooks are observers of request lifecycle events.
+	Hooks Hooks
 }
 
+// DuplicateIDPolicy defines how batch calls with the same id are handled. Ids are compared with ID.Equal.
+type DuplicateIDPolicy int
+
+const (
+	// DuplicateIDAllow dispatches calls with duplicate ids.
+	DuplicateIDAllow DuplicateIDPolicy = iota
+	// DuplicateIDRejectCall rejects every call with duplicate id with invalid request error, other calls are
+	// dispatched.
+	DuplicateIDRejectCall
+	// DuplicateIDRejectBatch rejects the whole batch with invalid request error.
+	DuplicateIDRejectBatch
+)
+
 // NewServer create server with provided options.
 func NewServer(opts Options) *Server {
 	if opts.BatchMaxLen == 0 {
 		opts.BatchMaxLen = defaultBatchMaxLen
 	}
 
-	if opts.ContentType == "" {
-		opts.ContentType = contentTypeJSON
+	if opts.MaxBodySize == 0 {
+		opts.MaxBodySize = defaultMaxBodySize
+	}
+
+	if opts.CompressMinSize == 0 {
+		opts.CompressMinSize = defaultCompressMin
+	}
+
+	if opts.ShutdownError == nil {
+		opts.ShutdownError = ErrShuttingDownJSON()
+	}
+
+	if opts.RequestIDHeader == "" {
+		opts.RequestIDHeader = defaultRequestID
+	}
+
+	if opts.RequestIDGenerator == nil {
+		opts.RequestIDGenerator = newRequestID
 	}
 
-	return &Server{
-		options: opts,
+	if opts.ContentType != "" {
+		opts.ContentTypes = append([]string{opts.ContentType}, opts.ContentTypes...)
+	}
+
+	if len(opts.ContentTypes) == 0 {
+		opts.ContentTypes = []string{contentTypeJSON}
+	}
+
+	s := &Server{
+		options:  opts,
+		services: make(map[string]*Service),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	for _, contentType := range opts.ContentTypes {
+		mediaType := strings.ToLower(strings.TrimSpace(contentType))
+		s.registerCodec(&codecEntry{
+			mediaType:    mediaType,
+			responseType: mediaType + "; charset=utf-8",
+			codec:        jsonCodec{},
+		})
 	}
+
+	s.RegisterEncoding("gzip", gzipEncoding{})
+	s.RegisterEncoding("deflate", deflateEncoding{})
+
+	return s
 }
 
 // Register new json rpc method.
@@ -49,25 +149,28 @@ func (s *Server) Register(method string, h Handler) *Service {
 		panic("can not register service with empty method")
 	}
 
+	return s.register(method, h, nil)
+}
+
+func (s *Server) register(method string, h Handler, group *Group) *Service {
 	service := &Service{
 		name:    method,
 		handler: h,
+		group:   group,
 	}
 
-	s.services = append(s.services, service)
+	// the first registered service is called
+	if _, ok := s.services[method]; !ok {
+		s.services[method] = service
+	}
 
 	return service
 }
 
-// GetService get registered service by method name.
+// GetService get registered service by method name, including services of mounted servers.
 func (s *Server) GetService(method string) *Service {
-	for _, service := range s.services {
-		if service.name == method {
-			return service
-		}
-	}
-
-	return nil
+	service, _ := s.lookup(method)
+	return service
 }
 
 // Use appends a middleware handler to server. This middleware call for each service request.
@@ -79,3 +182,28 @@ func (s *Server) Use(middlewares ...MiddlewareFunc) {
 func (service *Service) Use(middlewares ...MiddlewareFunc) {
 	service.middlewares = append(service.middlewares, middlewares...)
 }
+
+// Require adds permission scopes required to call service. Scopes are checked by auth middleware.
+func (service *Service) Require(scopes ...string) *Service {
+	service.scopes = append(service.scopes, scopes...)
+	return service
+}
+
+// Name returns service method name.
+func (service *Service) Name() string {
+	return service.name
+}
+
+// Group returns full prefix of service group, empty string if service is registered without group.
+func (service *Service) Group() string {
+	if service.group == nil {
+		return ""
+	}
+
+	return service.group.fullPrefix()
+}
+
+// Scopes returns permission scopes required to call service.
+func (service *Service) Scopes() []string {
+	return service.scopes
+}
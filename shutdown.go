@@ -0,0 +1,65 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http"
+)
+
+// startRequest register in-flight request. Returns false if server is shutting down.
+func (s *Server) startRequest() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.shuttingDown {
+		return false
+	}
+
+	s.inFlight.Add(1)
+	return true
+}
+
+// isShuttingDown check if Shutdown was called.
+func (s *Server) isShuttingDown() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.shuttingDown
+}
+
+// withServerContext returns request with context, which is also canceled when server cancels running handlers.
+func (s *Server) withServerContext(r *http.Request) (*http.Request, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(r.Context())
+	stop := context.AfterFunc(s.ctx, cancel)
+
+	return r.WithContext(ctx), func() {
+		stop()
+		cancel()
+	}
+}
+
+// Shutdown gracefully shuts down the server. New calls are rejected with Options.ShutdownError and
+// "Connection: close" header, so clients do not reuse persistent connections. Shutdown waits for running
+// handlers, including batch calls, to finish. If ctx is done before that, handler contexts are canceled
+// and ctx error is returned.
+//
+// Shutdown does not close listeners, use http.Server Shutdown for it after Shutdown returns.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.shuttingDown = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(done)
+	}()
+
+	defer s.cancel()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
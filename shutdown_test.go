@@ -0,0 +1,117 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestShutdown(t *testing.T) {
+	rpc := NewServer(Options{})
+
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	rpc.Register("wait", func(ctx *RequestCtx) (Result, Error) {
+		started <- struct{}{}
+		<-release
+		return ctx.Result("done")
+	})
+
+	in := `[{"jsonrpc":"2.0","method":"wait","id":1},{"jsonrpc":"2.0","method":"wait","id":2}]`
+	w := httptest.NewRecorder()
+	served := make(chan struct{})
+
+	go func() {
+		r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(in))
+		r.Header.Set("Content-Type", "application/json")
+		rpc.ServeHTTP(w, r)
+		close(served)
+	}()
+
+	<-started
+	<-started
+
+	shutdown := make(chan error)
+	go func() {
+		shutdown <- rpc.Shutdown(context.Background())
+	}()
+
+	// wait until shutdown starts
+	for !rpc.isShuttingDown() {
+		time.Sleep(time.Millisecond)
+	}
+
+	rejected := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"jsonrpc":"2.0","method":"wait","id":3}`))
+	r.Header.Set("Content-Type", "application/json")
+	rpc.ServeHTTP(rejected, r)
+
+	expected := `{"jsonrpc":"2.0","error":{"code":-32000,"message":"Server shutting down"},"id":null}`
+	if !IsJSONEqual(expected, rejected.Body.String()) {
+		t.Errorf("Unexpected result. Expected %v. Got %v", expected, rejected.Body.String())
+		t.FailNow()
+	}
+
+	if rejected.Header().Get("Connection") != "close" {
+		t.Errorf("Expected Connection: close header")
+		t.FailNow()
+	}
+
+	select {
+	case err := <-shutdown:
+		t.Errorf("Shutdown returned before in-flight requests finished: %v", err)
+		t.FailNow()
+	default:
+	}
+
+	close(release)
+	<-served
+
+	if err := <-shutdown; err != nil {
+		t.Errorf("Received unexpected error:\n%+v", err)
+		t.FailNow()
+	}
+
+	expected = `[{"jsonrpc":"2.0","result":"done","id":1},{"jsonrpc":"2.0","result":"done","id":2}]`
+	if !IsJSONEqual(expected, w.Body.String()) {
+		t.Errorf("Unexpected result. Expected %v. Got %v", expected, w.Body.String())
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	rpc := NewServer(Options{})
+
+	started := make(chan struct{})
+	rpc.Register("wait", func(ctx *RequestCtx) (Result, Error) {
+		close(started)
+		<-ctx.Context().Done()
+		return nil, ErrInternalJSON()
+	})
+
+	served := make(chan struct{})
+	go func() {
+		r, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"jsonrpc":"2.0","method":"wait","id":1}`))
+		r.Header.Set("Content-Type", "application/json")
+		rpc.ServeHTTP(httptest.NewRecorder(), r)
+		close(served)
+	}()
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := rpc.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Unexpected error. Expected %v. Got %v", context.DeadlineExceeded, err)
+		t.FailNow()
+	}
+
+	select {
+	case <-served:
+	case <-time.After(time.Second):
+		t.Errorf("Handler context was not canceled")
+	}
+}